@@ -1,20 +1,73 @@
 package lanky_crypto
 
 import (
+	"bytes"
+	"compress/gzip"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 )
 
+var (
+	// ErrEmptyCiphertext is returned by Decrypt and DecryptFromBytes when the encryption is empty.
+	ErrEmptyCiphertext = errors.New("lanky crypto: empty ciphertext")
+
+	// ErrShortCiphertext is returned by Decrypt and DecryptFromBytes when the encryption is shorter than its IV.
+	ErrShortCiphertext = errors.New("lanky crypto: ciphertext shorter than its IV")
+
+	// ErrInvalidEncoding is returned by Decrypt and DecryptFromBytes when the encryption is not valid base64,
+	// meaning the input is malformed rather than encrypted with another secret.
+	ErrInvalidEncoding = errors.New("lanky crypto: invalid encoding")
+)
+
+// ErrIntegrity is returned by Decrypt when the integrity check is enabled and the
+// authentication tag of the encryption does not match, meaning it was corrupted or tampered with.
+var ErrIntegrity = errors.New("lanky crypto: integrity check failed")
+
+// macLabel is mixed with the secret to derive the HMAC key, so the MAC key differs from the encryption key.
+const macLabel = "lanky-crypto-mac"
+
+// The header flags prefixed to the plaintext when the compression is enabled.
+const (
+	flagRaw  byte = 0 // The payload is stored as is, since compressing it did not make it smaller.
+	flagGzip byte = 1 // The payload is gzip compressed.
+)
+
+// ErrCompression is returned by Decrypt when the compression is enabled and the decrypted payload
+// has no valid compression header, e.g. it was encrypted without WithCompression.
+var ErrCompression = errors.New("lanky crypto: invalid compression header")
+
+// Marshaler converts values to and from their byte representation for ToBytes and FromBytes,
+// e.g. to plug in a faster JSON encoder such as jsoniter on a hot publish path.
+type Marshaler interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// jsonMarshaler is the default Marshaler, backed by encoding/json.
+type jsonMarshaler struct{}
+
+func (jsonMarshaler) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonMarshaler) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
 // LankyCrypto is an interface that defines the methods for performing cryptographic operations.
 type LankyCrypto interface {
 	// ToBytes converts the given data to a byte slice.
 	// It returns the byte slice representation of the data and an error if any occurred.
 	ToBytes(data any) ([]byte, error)
 
+	// FromBytes is the inverse of ToBytes: it converts the given byte slice into the value pointed to by v.
+	FromBytes(data []byte, v any) error
+
 	// Encrypt encrypts the given byte slice and returns the encryption as a string.
+	// A random IV is generated for every encryption and prefixed to the ciphertext.
 	// It returns the encryption string and an error if any occurred.
 	Encrypt(data []byte) (encryption string, err error)
 
@@ -23,17 +76,96 @@ type LankyCrypto interface {
 	EncryptToBytes(data []byte) (encryption []byte, err error)
 
 	// Decrypt decrypts the given encryption string and returns the decrypted byte slice.
-	// It returns the decrypted byte slice and an error if any occurred.
+	// It returns the decrypted byte slice and an error if any occurred, ErrEmptyCiphertext for an empty input
+	// and ErrInvalidEncoding for an input that is not valid base64.
+	// It returns ErrShortCiphertext for an encryption shorter than its IV.
 	Decrypt(encryption string) (result []byte, err error)
 
 	// DecryptFromBytes decrypts the given encryption byte slice and returns the decrypted byte slice.
 	// It returns the decrypted byte slice and an error if any occurred.
 	DecryptFromBytes(encryption []byte) (result []byte, err error)
+
+	// EncryptStream encrypts everything read from src and writes the encryption to dst,
+	// without holding the whole payload in memory.
+	// A random IV is generated for every stream and written before the ciphertext,
+	// so the output can be decrypted by any LankyCrypto instance sharing the secret.
+	// The output is raw binary unless WithStreamBase64 is set. The integrity layer is not applied to streams.
+	EncryptStream(dst io.Writer, src io.Reader) error
+
+	// DecryptStream decrypts an encryption produced by EncryptStream read from src and writes the result to dst.
+	DecryptStream(dst io.Writer, src io.Reader) error
 }
 
 type lc struct {
-	secret string
-	size   []byte
+	secret       string
+	macKey       []byte
+	streamBase64 bool
+	encoding     *base64.Encoding
+	compress     bool
+	rotation     bool
+	previous     []string
+	marshaler    Marshaler
+}
+
+// Option is a function type that represents an option for configuring LankyCrypto.
+type Option func(c *lc)
+
+// WithIntegrity enables the encrypt-then-MAC layer.
+// An HMAC-SHA256 of the ciphertext, keyed with a key derived from the secret, is appended
+// to every encryption and verified in constant time before decrypting.
+// Decrypt returns ErrIntegrity when the verification fails.
+// Both sides must enable it, since the encryption format differs from the default one.
+func WithIntegrity() Option {
+	return func(c *lc) {
+		c.macKey = deriveMacKey(c.secret)
+	}
+}
+
+// WithStreamBase64 makes EncryptStream base64 encode its output and DecryptStream expect a base64 encoded input.
+func WithStreamBase64() Option {
+	return func(c *lc) {
+		c.streamBase64 = true
+	}
+}
+
+// WithCompression gzips the plaintext before Encrypt and decompresses it after Decrypt.
+// A one-byte header flag is prefixed to the plaintext, telling Decrypt whether the payload was compressed:
+// a payload that does not get smaller, e.g. already compressed data, is stored as is.
+// Both sides must enable it, since the encryption format differs from the default one.
+// The compression is not applied to streams.
+func WithCompression() Option {
+	return func(c *lc) {
+		c.compress = true
+	}
+}
+
+// WithEncoding replaces the base64.StdEncoding used to encode the encryptions and the base64 streams,
+// e.g. base64.URLEncoding or base64.RawURLEncoding for encryptions embedded in URLs or filenames.
+// A nil encoding keeps the default one. Both sides must use the same encoding.
+//
+// Example usage:
+//
+//	crypto := NewLankyCryptoWith(secret, WithEncoding(base64.RawURLEncoding))
+func WithEncoding(enc *base64.Encoding) Option {
+	return func(c *lc) {
+		if enc != nil {
+			c.encoding = enc
+		}
+	}
+}
+
+// WithMarshaler replaces the encoding/json marshaler used by ToBytes, FromBytes and DecryptInto.
+// A nil marshaler keeps the default one.
+//
+// Example usage:
+//
+//	crypto := NewLankyCryptoWith(secret, WithMarshaler(jsoniter.ConfigCompatibleWithStandardLibrary))
+func WithMarshaler(m Marshaler) Option {
+	return func(c *lc) {
+		if m != nil {
+			c.marshaler = m
+		}
+	}
 }
 
 // NewLankyCrypto creates a new instance of LankyCrypto with the given secret.
@@ -46,14 +178,30 @@ type lc struct {
 // Returns:
 //   - LankyCrypto: A new instance of LankyCrypto.
 func NewLankyCrypto(secret string) LankyCrypto {
-	blockBytes := make([]byte, 16)
-	rand.Read(blockBytes)
+	return NewLankyCryptoWith(secret)
+}
+
+// NewLankyCryptoWith creates a new instance of LankyCrypto with the given secret and options.
+// Without options it behaves exactly like NewLankyCrypto.
+//
+// Example usage:
+//
+//	crypto := NewLankyCryptoWith(secret, WithIntegrity())
+func NewLankyCryptoWith(secret string, opts ...Option) LankyCrypto {
+	c := &lc{secret: secret, encoding: base64.StdEncoding, marshaler: jsonMarshaler{}}
+	for _, opt := range opts {
+		opt(c)
+	}
 
-	return &lc{secret: secret, size: blockBytes}
+	return c
 }
 
 func (c *lc) ToBytes(data any) ([]byte, error) {
-	return json.Marshal(data)
+	return c.marshaler.Marshal(data)
+}
+
+func (c *lc) FromBytes(data []byte, v any) error {
+	return c.marshaler.Unmarshal(data, v)
 }
 
 func (c *lc) Encrypt(data []byte) (string, error) {
@@ -63,9 +211,29 @@ func (c *lc) Encrypt(data []byte) (string, error) {
 	}
 
 	plainText := data
-	cfb := cipher.NewCFBEncrypter(block, c.size)
-	cipherText := make([]byte, len(plainText))
-	cfb.XORKeyStream(cipherText, plainText)
+	if c.compress {
+		if plainText, err = compress(data); err != nil {
+			return "", err
+		}
+	}
+
+	// Each encryption starts with its own random IV, so any instance sharing the secret decrypts it.
+	cipherText := make([]byte, aes.BlockSize+len(plainText))
+	iv := cipherText[:aes.BlockSize]
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+
+	cfb := cipher.NewCFBEncrypter(block, iv)
+	cfb.XORKeyStream(cipherText[aes.BlockSize:], plainText)
+
+	if c.macKey != nil {
+		cipherText = append(cipherText, sign(c.macKey, cipherText)...)
+	}
+
+	if c.rotation {
+		cipherText = append([]byte{keyVersion(c.secret)}, cipherText...)
+	}
 
 	return c.encode(cipherText), nil
 }
@@ -79,20 +247,56 @@ func (c *lc) EncryptToBytes(data []byte) ([]byte, error) {
 }
 
 func (c *lc) Decrypt(encryption string) ([]byte, error) {
-	block, err := aes.NewCipher([]byte(c.secret))
-	if err != nil {
+	if _, err := aes.NewCipher([]byte(c.secret)); err != nil {
 		return nil, err
 	}
 
+	if len(encryption) == 0 {
+		return nil, ErrEmptyCiphertext
+	}
+
 	cipherText, err := c.decode(encryption)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEncoding, err)
+	}
+
+	if len(cipherText) == 0 {
+		return nil, ErrEmptyCiphertext
+	}
+
+	if c.rotation {
+		return c.decryptRotated(cipherText)
+	}
+
+	return c.decryptWith(c.secret, c.macKey, cipherText)
+}
+
+// decryptWith verifies the authentication tag with the MAC key, when not nil, and decrypts the ciphertext with the secret.
+func (c *lc) decryptWith(secret string, macKey []byte, cipherText []byte) ([]byte, error) {
+	block, err := aes.NewCipher([]byte(secret))
 	if err != nil {
 		return nil, err
 	}
 
-	cfb := cipher.NewCFBDecrypter(block, c.size)
+	if macKey != nil {
+		if cipherText, err = verify(macKey, cipherText); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cipherText) < aes.BlockSize {
+		return nil, ErrShortCiphertext
+	}
+	iv, cipherText := cipherText[:aes.BlockSize], cipherText[aes.BlockSize:]
+
+	cfb := cipher.NewCFBDecrypter(block, iv)
 	plainText := make([]byte, len(cipherText))
 	cfb.XORKeyStream(plainText, cipherText)
 
+	if c.compress {
+		return decompress(plainText)
+	}
+
 	return plainText, nil
 }
 
@@ -104,16 +308,157 @@ func (c *lc) DecryptFromBytes(encryption []byte) ([]byte, error) {
 	return dcr, nil
 }
 
-// encode encodes the given byte slice using base64 encoding and returns the encoded string.
+func (c *lc) EncryptStream(dst io.Writer, src io.Reader) error {
+	block, err := aes.NewCipher([]byte(c.secret))
+	if err != nil {
+		return err
+	}
+
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return err
+	}
+
+	var encoder io.WriteCloser
+	if c.streamBase64 {
+		encoder = base64.NewEncoder(c.encoding, dst)
+		dst = encoder
+	}
+
+	if _, err := dst.Write(iv); err != nil {
+		return err
+	}
+
+	writer := &cipher.StreamWriter{S: cipher.NewCFBEncrypter(block, iv), W: dst}
+	if _, err := io.Copy(writer, src); err != nil {
+		return err
+	}
+
+	if encoder != nil {
+		return encoder.Close()
+	}
+
+	return nil
+}
+
+func (c *lc) DecryptStream(dst io.Writer, src io.Reader) error {
+	block, err := aes.NewCipher([]byte(c.secret))
+	if err != nil {
+		return err
+	}
+
+	if c.streamBase64 {
+		src = base64.NewDecoder(c.encoding, src)
+	}
+
+	iv := make([]byte, aes.BlockSize)
+	if _, err := io.ReadFull(src, iv); err != nil {
+		return err
+	}
+
+	reader := &cipher.StreamReader{S: cipher.NewCFBDecrypter(block, iv), R: src}
+	_, err = io.Copy(dst, reader)
+	return err
+}
+
+// sign computes the HMAC-SHA256 of the given ciphertext with the derived MAC key.
+func sign(macKey []byte, cipherText []byte) []byte {
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(cipherText)
+	return mac.Sum(nil)
+}
+
+// verify splits the authentication tag from the given payload and compares it in constant time
+// with the expected one. It returns the ciphertext without the tag, or ErrIntegrity on mismatch.
+func verify(macKey []byte, payload []byte) ([]byte, error) {
+	if len(payload) < sha256.Size {
+		return nil, ErrIntegrity
+	}
+
+	cipherText, tag := payload[:len(payload)-sha256.Size], payload[len(payload)-sha256.Size:]
+	if !hmac.Equal(tag, sign(macKey, cipherText)) {
+		return nil, ErrIntegrity
+	}
+
+	return cipherText, nil
+}
+
+// compress gzips the given data and prefixes the header flag. The data is kept as is
+// when the compressed form is not smaller.
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(flagGzip)
+
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	if buf.Len() > len(data) {
+		return append([]byte{flagRaw}, data...), nil
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decompress reads the header flag of the given payload and returns the original data.
+// It returns ErrCompression when the header is missing or unknown.
+func decompress(payload []byte) ([]byte, error) {
+	if len(payload) == 0 {
+		return nil, ErrCompression
+	}
+
+	switch payload[0] {
+	case flagRaw:
+		return payload[1:], nil
+	case flagGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(payload[1:]))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCompression, err)
+		}
+		defer zr.Close()
+		return io.ReadAll(zr)
+	default:
+		return nil, ErrCompression
+	}
+}
+
+// encode encodes the given byte slice using the configured base64 encoding and returns the encoded string.
 // It takes a byte slice as input and returns a string.
 func (c *lc) encode(src []byte) string {
-	return base64.StdEncoding.EncodeToString(src)
+	return c.encoding.EncodeToString(src)
 }
 
-// decode decodes a base64 encoded string and returns the decoded byte slice.
+// decode decodes a string encoded with the configured base64 encoding and returns the decoded byte slice.
 // It takes a string as input and returns a byte slice and an error.
 // If the decoding is successful, the error will be nil.
 // If an error occurs during decoding, the error will be non-nil.s
 func (c *lc) decode(str string) ([]byte, error) {
-	return base64.StdEncoding.DecodeString(str)
+	return c.encoding.DecodeString(str)
+}
+
+// DecryptInto decrypts the given encryption byte slice and unmarshals the result into a value of type T
+// with FromBytes, so the configured Marshaler is honored. It is the inverse of encrypting the output of ToBytes.
+// On failure it returns the zero value of T and the error.
+//
+// Example usage:
+//
+//	order, err := DecryptInto[Order](crypto, msg.Body)
+func DecryptInto[T any](c LankyCrypto, encryption []byte) (T, error) {
+	var result T
+
+	decrypted, err := c.DecryptFromBytes(encryption)
+	if err != nil {
+		return result, err
+	}
+
+	if err := c.FromBytes(decrypted, &result); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return result, nil
 }
@@ -0,0 +1,119 @@
+package lanky_crypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func TestCompressionRoundTrip(t *testing.T) {
+	incompressible := make([]byte, 4096)
+	if _, err := rand.Read(incompressible); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "highly compressible", data: bytes.Repeat([]byte(`{"event":"order.created","status":"pending"}`), 200)},
+		{name: "incompressible", data: incompressible},
+		{name: "empty", data: []byte{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			crp := NewLankyCryptoWith(testSecret, WithCompression())
+
+			enc, err := crp.EncryptToBytes(tt.data)
+			if err != nil {
+				t.Fatalf("EncryptToBytes() error = %v", err)
+			}
+
+			dec, err := crp.DecryptFromBytes(enc)
+			if err != nil {
+				t.Fatalf("DecryptFromBytes() error = %v", err)
+			}
+
+			if !bytes.Equal(dec, tt.data) {
+				t.Fatalf("round trip mismatch: got %d bytes, want %d bytes", len(dec), len(tt.data))
+			}
+		})
+	}
+}
+
+func TestCompressionShrinksCompressibleInput(t *testing.T) {
+	data := bytes.Repeat([]byte(`{"event":"order.created","status":"pending"}`), 200)
+
+	plain, err := NewLankyCryptoWith(testSecret).EncryptToBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compressed, err := NewLankyCryptoWith(testSecret, WithCompression()).EncryptToBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(compressed) >= len(plain) {
+		t.Fatalf("expected the compressed encryption to be smaller, got %d bytes, uncompressed %d bytes", len(compressed), len(plain))
+	}
+}
+
+func TestDecryptMalformedInput(t *testing.T) {
+	crp := NewLankyCryptoWith(testSecret, WithIntegrity())
+
+	enc, err := crp.Encrypt([]byte(`{"event":"order.created"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  error
+	}{
+		{name: "empty", input: "", want: ErrEmptyCiphertext},
+		{name: "not base64", input: "not base64 at all!", want: ErrInvalidEncoding},
+		{name: "truncated encoding", input: enc[:len(enc)-3], want: ErrInvalidEncoding},
+		{name: "truncated ciphertext", input: base64.StdEncoding.EncodeToString(raw[:len(raw)-8]), want: ErrIntegrity},
+		{name: "shorter than the tag", input: base64.StdEncoding.EncodeToString(raw[:8]), want: ErrIntegrity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := crp.Decrypt(tt.input); !errors.Is(err, tt.want) {
+				t.Fatalf("Decrypt() error = %v, want %v", err, tt.want)
+			}
+			if _, err := crp.DecryptFromBytes([]byte(tt.input)); !errors.Is(err, tt.want) {
+				t.Fatalf("DecryptFromBytes() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecryptWithAnotherInstance(t *testing.T) {
+	plain := []byte(`{"event":"order.created","id":"0123456789abcdef"}`)
+
+	enc, err := NewLankyCrypto(testSecret).Encrypt(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := NewLankyCrypto(testSecret).Decrypt(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, plain) {
+		t.Fatalf("Decrypt() = %q, want %q", got, plain)
+	}
+}
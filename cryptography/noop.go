@@ -0,0 +1,54 @@
+package lanky_crypto
+
+import "io"
+
+// noop is a LankyCrypto passing the payloads through without encryption.
+type noop struct {
+	marshaler Marshaler
+}
+
+// NewNoOpCrypto creates a LankyCrypto that does not encrypt anything: Encrypt and Decrypt return their input as is,
+// so the payloads stay readable, e.g. in the RabbitMQ management UI during local development.
+// Only the WithMarshaler option is honored. Never use it in production.
+func NewNoOpCrypto(opts ...Option) LankyCrypto {
+	c := &lc{marshaler: jsonMarshaler{}}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return &noop{marshaler: c.marshaler}
+}
+
+func (n *noop) ToBytes(data any) ([]byte, error) {
+	return n.marshaler.Marshal(data)
+}
+
+func (n *noop) FromBytes(data []byte, v any) error {
+	return n.marshaler.Unmarshal(data, v)
+}
+
+func (n *noop) Encrypt(data []byte) (string, error) {
+	return string(data), nil
+}
+
+func (n *noop) EncryptToBytes(data []byte) ([]byte, error) {
+	return append([]byte(nil), data...), nil
+}
+
+func (n *noop) Decrypt(encryption string) ([]byte, error) {
+	return []byte(encryption), nil
+}
+
+func (n *noop) DecryptFromBytes(encryption []byte) ([]byte, error) {
+	return append([]byte(nil), encryption...), nil
+}
+
+func (n *noop) EncryptStream(dst io.Writer, src io.Reader) error {
+	_, err := io.Copy(dst, src)
+	return err
+}
+
+func (n *noop) DecryptStream(dst io.Writer, src io.Reader) error {
+	_, err := io.Copy(dst, src)
+	return err
+}
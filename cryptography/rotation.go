@@ -0,0 +1,98 @@
+package lanky_crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+// ErrNoMatchingKey is returned by Decrypt when the key rotation is enabled and neither the primary secret
+// nor any previous secret can decrypt the encryption.
+var ErrNoMatchingKey = errors.New("lanky crypto: no secret matches the encryption")
+
+// WithKeyRotation enables the key rotation, the secret given to NewLankyCryptoWith being the primary one.
+// Encrypt always uses the primary secret and prefixes the encryption with a one-byte key version derived from it.
+// Decrypt reads the version and tries the primary secret, then each previous secret in order, among the ones
+// matching it. It returns ErrNoMatchingKey when none of them decrypts the encryption.
+// With WithIntegrity, Decrypt falls back to the unversioned format of the instances without the key rotation,
+// the authentication tag telling the formats apart, so the consumers can enable it before the publishers.
+// Without WithIntegrity both sides must enable it together, a versioned encryption being indistinguishable.
+// Once enabled, the previous secrets can be added and removed freely, the format staying the same.
+//
+// Example usage:
+//
+//	crypto := NewLankyCryptoWith(newSecret, WithIntegrity(), WithKeyRotation(oldSecret))
+func WithKeyRotation(previous ...string) Option {
+	return func(c *lc) {
+		c.rotation = true
+		c.previous = append(c.previous, previous...)
+	}
+}
+
+// keyVersion derives the one-byte version of the secret prefixed to the encryptions.
+func keyVersion(secret string) byte {
+	sum := sha256.Sum256([]byte(secret))
+	return sum[0]
+}
+
+// deriveMacKey derives the HMAC key of the integrity layer from the secret.
+func deriveMacKey(secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(macLabel))
+	return mac.Sum(nil)
+}
+
+// decryptRotated decrypts a payload prefixed with its key version, see decryptVersioned. When it fails and the
+// integrity check is enabled, the payload is decrypted as an unversioned encryption of one of the secrets instead.
+func (c *lc) decryptRotated(payload []byte) ([]byte, error) {
+	plainText, err := c.decryptVersioned(payload)
+	if err == nil || c.macKey == nil {
+		return plainText, err
+	}
+
+	for _, secret := range c.secrets() {
+		if plainText, uerr := c.decryptWith(secret, deriveMacKey(secret), payload); uerr == nil {
+			return plainText, nil
+		}
+	}
+
+	return nil, err
+}
+
+// decryptVersioned decrypts a payload prefixed with its key version with the primary secret, then the previous ones,
+// skipping the secrets of another version.
+func (c *lc) decryptVersioned(payload []byte) ([]byte, error) {
+	version, cipherText := payload[0], payload[1:]
+	if len(cipherText) == 0 {
+		return nil, ErrEmptyCiphertext
+	}
+
+	var errs []error
+	for _, secret := range c.secrets() {
+		if keyVersion(secret) != version {
+			continue
+		}
+
+		var macKey []byte
+		if c.macKey != nil {
+			macKey = deriveMacKey(secret)
+		}
+
+		plainText, err := c.decryptWith(secret, macKey, cipherText)
+		if err == nil {
+			return plainText, nil
+		}
+		errs = append(errs, err)
+	}
+
+	if len(errs) == 0 {
+		return nil, ErrNoMatchingKey
+	}
+	return nil, fmt.Errorf("%w: %w", ErrNoMatchingKey, errors.Join(errs...))
+}
+
+// secrets returns the primary secret followed by the previous ones.
+func (c *lc) secrets() []string {
+	return append([]string{c.secret}, c.previous...)
+}
@@ -1,13 +1,20 @@
 package lanky_mongo
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	llg "github.com/the-lanky/go/log"
 	llt "github.com/the-lanky/go/types"
-	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
@@ -18,16 +25,84 @@ type LankyMongo interface {
 	// Database returns the MongoDB database instance.
 	Database() *mongo.Database
 
+	// UseDatabase returns the database with the given name on the same connection, e.g. for services
+	// using several databases of the cluster. The configured database returned by Database is left unchanged.
+	UseDatabase(name string) *mongo.Database
+
 	// Client returns the MongoDB client instance.
 	Client() *mongo.Client
 
-	// Close closes the connection to the MongoDB server.
-	Close()
+	// Close closes the connection to the MongoDB server and returns the error, letting the caller decide how to handle it.
+	// Close and CloseCtx are idempotent: once the connection is closed, further calls are a no-op.
+	// A failed close is not recorded, so it can be retried.
+	Close() error
+
+	// CloseCtx closes the connection to the MongoDB server like Close, within the deadline of the context.
+	CloseCtx(ctx context.Context) error
+
+	// EnsureIndexes creates the given indexes on the collection of the configured database.
+	// Existing indexes with the same specification are left untouched, so it is safe to call on every startup.
+	EnsureIndexes(ctx context.Context, collection string, models []mongo.IndexModel) error
+
+	// Watch opens a change stream on the collection and dispatches each event's full document to the handler
+	// until the context is cancelled. Events without a full document (e.g. deletes) are dispatched as the raw event.
+	// If the stream is interrupted it is reopened from the last resume token so no event is missed, retrying
+	// the opening with a growing delay while it fails with a transient error, e.g. during an election.
+	// The stream starts after the token given with WithResumeAfter, e.g. the one saved by the handler given
+	// with WithResumeTokenHandler, so a restart of the process does not miss events either.
+	// It returns nil when the context is cancelled, the first error returned by the handler,
+	// or the error of an opening that is not transient.
+	Watch(ctx context.Context, collection string, pipeline mongo.Pipeline, handler func(bson.Raw) error, opts ...WatchOption) error
+
+	// WithRetry runs fn up to attempts times while it fails with a transient error
+	// (network error, timeout, not-primary during an election), doubling the delay after each attempt.
+	// It returns immediately on success, on a non-retryable error or when the context is done.
+	WithRetry(ctx context.Context, attempts int, delay time.Duration, fn func(ctx context.Context) error) error
+}
+
+// watchRetryDelay is the delay before reopening an interrupted change stream, doubled after each failed opening
+// up to watchMaxRetryDelay.
+const (
+	watchRetryDelay    = time.Second
+	watchMaxRetryDelay = time.Second * 30
+)
+
+// watchOptions holds the options of Watch.
+type watchOptions struct {
+	resumeAfter   bson.Raw
+	onResumeToken func(token bson.Raw)
 }
 
+// WatchOption is a function type that represents an option of Watch.
+type WatchOption func(o *watchOptions)
+
+// WithResumeAfter starts the change stream after the event of the given resume token instead of now.
+func WithResumeAfter(token bson.Raw) WatchOption {
+	return func(o *watchOptions) {
+		o.resumeAfter = token
+	}
+}
+
+// WithResumeTokenHandler sets the function invoked with the resume token of the change stream each time it moves,
+// after each handled event and each batch, e.g. to save it and watch again with WithResumeAfter after a restart.
+func WithResumeTokenHandler(fn func(token bson.Raw)) WatchOption {
+	return func(o *watchOptions) {
+		o.onResumeToken = fn
+	}
+}
+
+// defaultConnectionTimeout bounds the initial connection and ping when the configuration does not set a timeout.
+const defaultConnectionTimeout = time.Second * 10
+
 // libPrefix is the prefix used for MongoDB related constants in the library.
 const libPrefix = "MONGODB"
 
+// The connection protocols accepted in LankyMongoConf.Protocol.
+const (
+	protocolMongo    = "mongodb"
+	protocolMongoSrv = "mongodb+srv"
+)
+
 // fatal is a helper function that logs a fatal error message and exits the program.
 // It takes a logrus.Logger instance, a message string, and an error as input.
 // If the error is nil, it logs the message with a fatal level and exits the program.
@@ -72,7 +147,7 @@ func databaseValidation(conf *llt.LankyMongoConf, logger *logrus.Logger) {
 		fatal(logger, "Protocol is required", nil)
 	}
 
-	if conf.Protocol != "mongodb" && conf.Protocol != "mongodb+srv" {
+	if conf.Protocol != protocolMongo && conf.Protocol != protocolMongoSrv {
 		fatal(logger, "Protocol should be mongodb or mongodb+srv", nil)
 	}
 
@@ -84,15 +159,33 @@ func databaseValidation(conf *llt.LankyMongoConf, logger *logrus.Logger) {
 		conf.Host = "localhost"
 	}
 
-	if conf.Port == "" && conf.Protocol == "mongodb" {
+	if conf.Port == "" && conf.Protocol == protocolMongo {
 		fatal(logger, "Port is required", nil)
 	}
+
+	if conf.TLSCAFile != "" {
+		if _, err := os.Stat(conf.TLSCAFile); err != nil {
+			fatal(logger, "TLS CA file is not accessible", err)
+		}
+	}
+
+	if conf.TLSCertKeyFile != "" {
+		if _, err := os.Stat(conf.TLSCertKeyFile); err != nil {
+			fatal(logger, "TLS certificate key file is not accessible", err)
+		}
+	}
+
+	switch conf.MonitorLevel {
+	case "", MonitorOff, MonitorCommands, MonitorFull:
+	default:
+		fatal(logger, "MonitorLevel should be off, commands or full", nil)
+	}
 }
 
 // buildDsn constructs a MongoDB connection string based on the provided configuration.
 // It takes a llt.LankyMongoConf object as input and returns a string representing the connection string.
 // The connection string is built using the protocol, user, password, host, port, and database information from the configuration.
-// If the protocol is "mongo+srv", the connection string is constructed without specifying the port.
+// If the protocol is "mongodb+srv", the connection string is constructed without specifying the port.
 // If the configuration has any additional option parameters, they are appended to the connection string.
 // The constructed connection string is then returned.
 func buildDsn(conf llt.LankyMongoConf) string {
@@ -106,7 +199,7 @@ func buildDsn(conf llt.LankyMongoConf) string {
 		conf.Database,
 	)
 
-	if conf.Protocol == "mongo+srv" {
+	if conf.Protocol == protocolMongoSrv {
 		dsn = fmt.Sprintf(
 			"%s://%s:%s@%s/%s",
 			conf.Protocol,
@@ -124,70 +217,73 @@ func buildDsn(conf llt.LankyMongoConf) string {
 	return dsn
 }
 
-// buildReadPreference is a function that builds and returns a modified options.ClientOptions based on the provided readPreference.
-// It takes in a pointer to options.ClientOptions and a string representing the readPreference.
-// The readPreference can be one of the following values: "primary", "primaryPreferred", "secondary", "secondaryPreferred", or "nearest".
-// If the readPreference is not one of the valid options, it defaults to "primary".
-// The function sets the readPreference on the options.ClientOptions based on the provided readPreference value.
-// It returns the modified options.ClientOptions.
-func buildReadPreference(opt *options.ClientOptions, readPreference string) *options.ClientOptions {
+// parseReadPreference returns the read preference matching the given name.
+// The name can be one of the following values: "primary", "primaryPreferred", "secondary", "secondaryPreferred", or "nearest".
+// If the name is not one of the valid options, it defaults to "primary".
+func parseReadPreference(readPreference string) *readpref.ReadPref {
 	switch readPreference {
-	case "primary":
-		opt.SetReadPreference(readpref.Primary())
 	case "primaryPreferred":
-		opt.SetReadPreference(readpref.PrimaryPreferred())
+		return readpref.PrimaryPreferred()
 	case "secondary":
-		opt.SetReadPreference(readpref.Secondary())
+		return readpref.Secondary()
 	case "secondaryPreferred":
-		opt.SetReadPreference(readpref.SecondaryPreferred())
+		return readpref.SecondaryPreferred()
 	case "nearest":
-		opt.SetReadPreference(readpref.Nearest())
+		return readpref.Nearest()
 	default:
-		opt.SetReadPreference(readpref.Primary())
+		return readpref.Primary()
 	}
+}
 
+// buildReadPreference is a function that builds and returns a modified options.ClientOptions based on the provided readPreference.
+// It takes in a pointer to options.ClientOptions and a string representing the readPreference.
+// The readPreference is resolved with parseReadPreference, defaulting to "primary".
+// It returns the modified options.ClientOptions.
+func buildReadPreference(opt *options.ClientOptions, readPreference string) *options.ClientOptions {
+	opt.SetReadPreference(parseReadPreference(readPreference))
 	return opt
 }
 
-// buildMonitor is a function that creates and configures a command monitor for MongoDB client options.
-// It takes in a pointer to a ClientOptions struct and a logger from the logrus package.
-// The monitor is responsible for logging information about MongoDB commands.
-// It sets the Started, Succeeded, and Failed event handlers to log the relevant information.
-// The Started event handler logs the database name, command name, and command string.
-// The Succeeded event handler logs the database name, command name, duration, and reply string.
-// The Failed event handler logs the database name, command name, duration, and failure message.
-// Finally, it sets the monitor on the ClientOptions and returns the modified options.
-func buildMonitor(opt *options.ClientOptions, logger *logrus.Logger) *options.ClientOptions {
-	monitor := &event.CommandMonitor{
-		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
-			logger.Infof(
-				"[%s] [%s] %s",
-				e.DatabaseName,
-				e.CommandName,
-				e.Command.String(),
-			)
-		},
-		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
-			logger.Infof(
-				"[%s] [%s] [%s] %s",
-				e.DatabaseName,
-				e.CommandName,
-				e.Duration.String(),
-				e.Reply.String(),
-			)
-		},
-		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
-			logger.Errorf(
-				"[%s] [%s] [%s] %s",
-				e.DatabaseName,
-				e.CommandName,
-				e.Duration.String(),
-				e.Failure,
-			)
-		},
-	}
-	opt = opt.SetMonitor(monitor)
-	return opt
+// buildTLSConfig builds the tls.Config for the MongoDB connection from the TLS fields of the configuration.
+// It returns nil when none of the TLS fields are set, leaving the connection string in charge of TLS.
+// The CA file is used as the root pool to verify the server, and the certificate key file
+// is expected to contain both the client certificate and its private key, like the mongo shell's tlsCertificateKeyFile.
+func buildTLSConfig(conf llt.LankyMongoConf) (*tls.Config, error) {
+	if conf.TLSCAFile == "" && conf.TLSCertKeyFile == "" && !conf.TLSInsecure {
+		return nil, nil
+	}
+
+	tlsConf := &tls.Config{
+		InsecureSkipVerify: conf.TLSInsecure,
+	}
+
+	if conf.TLSCAFile != "" {
+		ca, err := os.ReadFile(conf.TLSCAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.New("no valid certificate found in the TLS CA file")
+		}
+		tlsConf.RootCAs = pool
+	}
+
+	if conf.TLSCertKeyFile != "" {
+		pem, err := os.ReadFile(conf.TLSCertKeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		cert, err := tls.X509KeyPair(pem, pem)
+		if err != nil {
+			return nil, err
+		}
+		tlsConf.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConf, nil
 }
 
 // buildSuccessMessage generates a success message indicating a successful connection to a MongoDB database.
@@ -228,7 +324,7 @@ func buildSuccessMessage(conf llt.LankyMongoConf) string {
 		conf.Database,
 	)
 
-	if conf.Protocol == "mongodb+srv" {
+	if conf.Protocol == protocolMongoSrv {
 		msg = fmt.Sprintf(
 			"Connected to mongo %s://%s@%s/%s",
 			conf.Protocol,
@@ -246,11 +342,13 @@ type mg struct {
 	client *mongo.Client
 	db     *mongo.Database
 	log    *logrus.Logger
+	closed atomic.Bool // Whether Close or CloseCtx was called, reset when the close failed.
 }
 
 // NewLankyMongo creates a new instance of LankyMongo, which is a MongoDB driver for the Lanky library.
 // It takes the following parameters:
 // - ctx: The context.Context object for managing the lifecycle of the MongoDB connection.
+// The initial connection and ping are bounded by conf.ConnectionTimeout (10 seconds when unset), so a wrong host fails fast.
 // - conf: The LankyMongoConf object containing the configuration for the MongoDB connection.
 // - logger: A pointer to a logrus.Logger object for logging purposes. If nil, a new instance of logrus.Logger will be created.
 //
@@ -275,36 +373,57 @@ func NewLankyMongo(
 
 	databaseValidation(&conf, logger)
 
+	connectionTimeout := defaultConnectionTimeout
+	if conf.ConnectionTimeout > 0 {
+		connectionTimeout = conf.ConnectionTimeout
+	}
+
 	dsn := buildDsn(conf)
 	opt := options.Client()
 
 	opt = opt.ApplyURI(dsn)
 	opt = buildReadPreference(opt, conf.ReadPreferrence)
-	opt = opt.SetConnectTimeout(conf.ConnectionTimeout)
+	opt = opt.SetConnectTimeout(connectionTimeout)
 	opt = opt.SetMaxConnIdleTime(conf.MaxConnIdleTime)
-	opt = opt.SetHeartbeatInterval(conf.HeartbeatInterval)
 	opt = opt.SetMaxPoolSize(uint64(conf.MaxPoolSize))
 	opt = opt.SetMinPoolSize(uint64(conf.MinPoolSize))
 
+	// A zero interval is rejected by the driver, it keeps its default then.
+	if conf.HeartbeatInterval > 0 {
+		opt = opt.SetHeartbeatInterval(conf.HeartbeatInterval)
+	}
+
+	tlsConf, err := buildTLSConfig(conf)
+	if err != nil {
+		fatal(logger, "Failed to build the TLS configuration", err)
+	}
+
+	if tlsConf != nil {
+		opt = opt.SetTLSConfig(tlsConf)
+	}
+
 	if conf.EnabledMonitor {
-		opt = buildMonitor(opt, logger)
+		opt = buildMonitor(opt, logger, conf.MonitorLevel, conf.MonitorDebug)
 	}
 
-	client, err := mongo.Connect(ctx, opt)
+	connectCtx, cancel := context.WithTimeout(ctx, connectionTimeout)
+	defer cancel()
+
+	client, err := mongo.Connect(connectCtx, opt)
 	if err != nil {
-		fatal(logger, "Failed to create mongodb connection", err)
+		fatal(logger, fmt.Sprintf("Failed to create mongodb connection to %s", llt.RedactDSN(dsn)), err)
 	}
 
-	err = client.Ping(ctx, opt.ReadPreference)
+	err = client.Ping(connectCtx, parseReadPreference(conf.ReadPreferrence))
 	if err != nil {
-		fatal(logger, "Failed to ping mongodb server", err)
+		fatal(logger, fmt.Sprintf("Failed to ping mongodb server within %s", connectionTimeout), err)
 	}
 
 	success(logger, buildSuccessMessage(conf))
 
 	return &mg{
 		ctx:    ctx,
-		db:     nil,
+		db:     client.Database(conf.Database),
 		client: client,
 		log:    logger,
 	}
@@ -314,14 +433,156 @@ func (c *mg) Database() *mongo.Database {
 	return c.db
 }
 
+func (c *mg) UseDatabase(name string) *mongo.Database {
+	return c.client.Database(name)
+}
+
 func (c *mg) Client() *mongo.Client {
 	return c.client
 }
 
-func (c *mg) Close() {
+func (c *mg) Close() error {
+	if !c.closed.CompareAndSwap(false, true) {
+		success(c.log, "Connection already closed")
+		return nil
+	}
+
 	if err := c.client.Disconnect(c.ctx); err != nil {
-		fatal(c.log, "Failed disconnecting mongodb", err)
-	} else {
-		success(c.log, "Connection successully closed")
+		c.closed.Store(false)
+		c.log.Errorf("❌ [%s] Failed disconnecting mongodb: %+v", libPrefix, err)
+		return err
+	}
+
+	success(c.log, "Connection successully closed")
+	return nil
+}
+
+func (c *mg) CloseCtx(ctx context.Context) error {
+	if !c.closed.CompareAndSwap(false, true) {
+		success(c.log, "Connection already closed")
+		return nil
+	}
+
+	if err := c.client.Disconnect(ctx); err != nil {
+		c.closed.Store(false)
+		c.log.Errorf("❌ [%s] Failed disconnecting mongodb: %+v", libPrefix, err)
+		return err
+	}
+
+	success(c.log, "Connection successully closed")
+	return nil
+}
+
+func (c *mg) EnsureIndexes(ctx context.Context, collection string, models []mongo.IndexModel) error {
+	if len(models) == 0 {
+		return nil
+	}
+
+	names, err := c.Database().Collection(collection).Indexes().CreateMany(ctx, models)
+	if err != nil {
+		c.log.Errorf("❌ [%s] Failed to create indexes on collection %s: %+v", libPrefix, collection, err)
+		return err
+	}
+
+	for _, name := range names {
+		success(c.log, fmt.Sprintf("Index %s ensured on collection %s", name, collection))
+	}
+
+	return nil
+}
+
+func (c *mg) Watch(
+	ctx context.Context,
+	collection string,
+	pipeline mongo.Pipeline,
+	handler func(bson.Raw) error,
+	opts ...WatchOption,
+) error {
+	var wo watchOptions
+	for _, opt := range opts {
+		opt(&wo)
+	}
+
+	resumeToken := wo.resumeAfter
+	saveToken := func(token bson.Raw) {
+		if token == nil || bytes.Equal(token, resumeToken) {
+			return
+		}
+		resumeToken = token
+		if wo.onResumeToken != nil {
+			wo.onResumeToken(token)
+		}
+	}
+
+	if pipeline == nil {
+		pipeline = mongo.Pipeline{}
+	}
+
+	coll := c.Database().Collection(collection)
+	delay := watchRetryDelay
+
+	for {
+		opt := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+		if resumeToken != nil {
+			opt = opt.SetResumeAfter(resumeToken)
+		}
+
+		stream, err := coll.Watch(ctx, pipeline, opt)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if !isRetryable(err) {
+				c.log.Errorf("❌ [%s] Failed to open change stream on collection %s: %+v", libPrefix, collection, err)
+				return err
+			}
+
+			c.log.Warnf("⚠️ [%s] Failed to open change stream on collection %s, retrying in %s: %+v", libPrefix, collection, delay, err)
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(delay):
+			}
+
+			delay = min(delay*2, watchMaxRetryDelay)
+			continue
+		}
+
+		delay = watchRetryDelay
+		success(c.log, fmt.Sprintf("Watching collection %s", collection))
+
+		for stream.Next(ctx) {
+			doc, ok := stream.Current.Lookup("fullDocument").DocumentOK()
+			if !ok {
+				doc = stream.Current
+			}
+
+			if err := handler(doc); err != nil {
+				stream.Close(context.Background())
+				return err
+			}
+
+			saveToken(stream.ResumeToken())
+		}
+
+		// The post-batch resume token covers the events scanned without being dispatched, e.g. an interruption
+		// before the first event, so the stream is not reopened from now.
+		saveToken(stream.ResumeToken())
+
+		err = stream.Err()
+		stream.Close(context.Background())
+
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		c.log.Warnf("⚠️ [%s] Change stream on collection %s interrupted, reconnecting: %+v", libPrefix, collection, err)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(watchRetryDelay):
+		}
 	}
 }
@@ -0,0 +1,131 @@
+package lanky_mongo
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// The verbosity levels of the command monitor, see LankyMongoConf.MonitorLevel.
+const (
+	MonitorOff      = "off"      // Nothing is logged.
+	MonitorCommands = "commands" // The database, command name and duration are logged, without any body.
+	MonitorFull     = "full"     // The command bodies are logged too, with the sensitive fields redacted.
+)
+
+// redacted replaces the value of the sensitive fields in the logged commands.
+const redacted = "[REDACTED]"
+
+// sensitiveFields lists the lower-cased names of the command fields whose value is never logged.
+var sensitiveFields = map[string]struct{}{
+	"pwd":           {},
+	"password":      {},
+	"passwd":        {},
+	"secret":        {},
+	"token":         {},
+	"accesstoken":   {},
+	"apikey":        {},
+	"authorization": {},
+	"credentials":   {},
+	"payload":       {},
+}
+
+// buildMonitor creates and configures a command monitor for MongoDB client options, logging at the given level.
+// At commands level, the Started event handler logs the database name and command name, the Succeeded and Failed
+// event handlers add the duration. At full level, the Started event handler also logs the command with its sensitive
+// fields redacted, the Failed event handler logs the failure message, and the Succeeded event handler logs the reply
+// only when debug is set. At off level, the options are returned without a monitor.
+func buildMonitor(opt *options.ClientOptions, logger *logrus.Logger, level string, debug bool) *options.ClientOptions {
+	if level == MonitorOff {
+		return opt
+	}
+
+	full := level != MonitorCommands
+
+	monitor := &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			if !full {
+				logger.Infof("[%s] [%s]", e.DatabaseName, e.CommandName)
+				return
+			}
+			logger.Infof(
+				"[%s] [%s] %s",
+				e.DatabaseName,
+				e.CommandName,
+				redactCommand(e.Command),
+			)
+		},
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			if !full || !debug {
+				logger.Infof("[%s] [%s] [%s]", e.DatabaseName, e.CommandName, e.Duration.String())
+				return
+			}
+			logger.Infof(
+				"[%s] [%s] [%s] %s",
+				e.DatabaseName,
+				e.CommandName,
+				e.Duration.String(),
+				redactCommand(e.Reply),
+			)
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			if !full {
+				logger.Errorf("[%s] [%s] [%s]", e.DatabaseName, e.CommandName, e.Duration.String())
+				return
+			}
+			logger.Errorf(
+				"[%s] [%s] [%s] %s",
+				e.DatabaseName,
+				e.CommandName,
+				e.Duration.String(),
+				e.Failure,
+			)
+		},
+	}
+	opt = opt.SetMonitor(monitor)
+	return opt
+}
+
+// redactCommand returns the extended JSON of the document with the value of its sensitive fields,
+// at any depth, replaced by a placeholder.
+func redactCommand(doc bson.Raw) string {
+	var d bson.D
+	if err := bson.Unmarshal(doc, &d); err != nil {
+		return redacted
+	}
+
+	out, err := bson.MarshalExtJSON(redactDocument(d), false, false)
+	if err != nil {
+		return redacted
+	}
+	return string(out)
+}
+
+func redactDocument(d bson.D) bson.D {
+	for i, e := range d {
+		if _, ok := sensitiveFields[strings.ToLower(e.Key)]; ok {
+			d[i].Value = redacted
+			continue
+		}
+		d[i].Value = redactValue(e.Value)
+	}
+	return d
+}
+
+func redactValue(v any) any {
+	switch value := v.(type) {
+	case bson.D:
+		return redactDocument(value)
+	case bson.A:
+		for i := range value {
+			value[i] = redactValue(value[i])
+		}
+		return value
+	default:
+		return v
+	}
+}
@@ -0,0 +1,107 @@
+package lanky_mongo
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrNotFound is returned by FindOne when no document matches the filter, instead of mongo.ErrNoDocuments.
+var ErrNotFound = errors.New("lanky mongo: document not found")
+
+// Aggregate runs the pipeline on the collection of the configured database and decodes every resulting
+// document into a value of type T. Generic methods are not allowed in Go, so it takes the LankyMongo instance.
+// The cursor is always closed. It returns the first error of the aggregation, the decoding or the cursor,
+// or the error of the context when it is cancelled while iterating.
+//
+// Example usage:
+//
+//	totals, err := Aggregate[OrderTotal](ctx, db, "orders", mongo.Pipeline{
+//	    {{Key: "$group", Value: bson.D{{Key: "_id", Value: "$customer"}, {Key: "total", Value: bson.D{{Key: "$sum", Value: "$amount"}}}}}},
+//	})
+func Aggregate[T any](ctx context.Context, c LankyMongo, collection string, pipeline mongo.Pipeline) ([]T, error) {
+	if pipeline == nil {
+		pipeline = mongo.Pipeline{}
+	}
+
+	cursor, err := c.Database().Collection(collection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeAll[T](ctx, cursor)
+}
+
+// FindOne finds the first document of the collection matching the filter and decodes it into a value of type T.
+// A nil filter matches every document. It returns ErrNotFound when no document matches.
+//
+// Example usage:
+//
+//	user, err := FindOne[User](ctx, db, "users", bson.D{{Key: "email", Value: email}})
+//	if errors.Is(err, ErrNotFound) {
+//	    // ...
+//	}
+func FindOne[T any](ctx context.Context, c LankyMongo, collection string, filter any) (T, error) {
+	var result T
+
+	if filter == nil {
+		filter = bson.D{}
+	}
+
+	err := c.Database().Collection(collection).FindOne(ctx, filter).Decode(&result)
+	if err != nil {
+		var zero T
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return zero, ErrNotFound
+		}
+		return zero, err
+	}
+
+	return result, nil
+}
+
+// Find finds the documents of the collection matching the filter and decodes them into a slice of T,
+// like Aggregate. A nil filter matches every document. No match returns an empty slice, not ErrNotFound.
+//
+// Example usage:
+//
+//	orders, err := Find[Order](ctx, db, "orders", bson.D{{Key: "status", Value: "paid"}}, options.Find().SetLimit(50))
+func Find[T any](ctx context.Context, c LankyMongo, collection string, filter any, opts ...*options.FindOptions) ([]T, error) {
+	if filter == nil {
+		filter = bson.D{}
+	}
+
+	cursor, err := c.Database().Collection(collection).Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeAll[T](ctx, cursor)
+}
+
+// decodeAll iterates the cursor, decoding every document into a value of type T, and closes it.
+func decodeAll[T any](ctx context.Context, cursor *mongo.Cursor) ([]T, error) {
+	defer cursor.Close(context.Background())
+
+	results := make([]T, 0, cursor.RemainingBatchLength())
+	for cursor.Next(ctx) {
+		var result T
+		if err := cursor.Decode(&result); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
@@ -0,0 +1,80 @@
+package lanky_mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// notPrimaryCodes are the server error codes returned while the replica set is electing a new primary.
+var notPrimaryCodes = []int{
+	10107, // NotWritablePrimary
+	13435, // NotPrimaryNoSecondaryOk
+	13436, // NotPrimaryOrSecondary
+	11602, // InterruptedDueToReplStateChange
+	189,   // PrimarySteppedDown
+	91,    // ShutdownInProgress
+}
+
+// isRetryable reports whether the error is transient: a network error, a timeout,
+// a not-primary error or an error labeled as retryable by the server.
+func isRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if mongo.IsNetworkError(err) || mongo.IsTimeout(err) {
+		return true
+	}
+
+	var se mongo.ServerError
+	if errors.As(err, &se) {
+		if se.HasErrorLabel("RetryableWriteError") || se.HasErrorLabel("TransientTransactionError") {
+			return true
+		}
+		for _, code := range notPrimaryCodes {
+			if se.HasErrorCode(code) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (c *mg) WithRetry(
+	ctx context.Context,
+	attempts int,
+	delay time.Duration,
+	fn func(ctx context.Context) error,
+) error {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for try := 1; try <= attempts; try++ {
+		if err = fn(ctx); err == nil || !isRetryable(err) {
+			return err
+		}
+
+		if try == attempts {
+			break
+		}
+
+		c.log.Warnf("⚠️ [%s] [%d/%d] Retryable error, retrying in %s: %+v", libPrefix, try, attempts, delay, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+	}
+
+	c.log.Errorf("❌ [%s] Giving up after %d attempts: %+v", libPrefix, attempts, err)
+	return err
+}
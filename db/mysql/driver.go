@@ -0,0 +1,245 @@
+package lanky_mysql
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net"
+	"os"
+	"sync/atomic"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/sirupsen/logrus"
+	llog "github.com/the-lanky/go/log"
+	llt "github.com/the-lanky/go/types"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	glog "gorm.io/gorm/logger"
+)
+
+// LankyMySqlDb is an interface that represents a connection to a MySQL database.
+type LankyMySqlDb interface {
+	// Db returns the underlying *gorm.DB instance.
+	Db() *gorm.DB
+
+	// Sql returns the underlying *sql.DB instance.
+	Sql() *sql.DB
+
+	// Close closes the database connection and returns the error, letting the caller decide how to handle it.
+	// Close and CloseCtx are idempotent: once the connection is closed, further calls are a no-op.
+	// A failed close is not recorded, so it can be retried.
+	Close() error
+
+	// CloseCtx closes the database connection like Close.
+	// It stops waiting and returns the context error once the context is done, leaving the close running in the background.
+	CloseCtx(ctx context.Context) error
+}
+
+// mysqlDb represents a MySQL database connection.
+type mysqlDb struct {
+	db    *gorm.DB       // The GORM database connection.
+	sqlDb *sql.DB        // The SQL database connection.
+	log   *logrus.Logger // The logger instance for logging.
+
+	closed atomic.Bool // Whether Close or CloseCtx was called, reset when the close failed.
+}
+
+// NewLankyMySql creates a new instance of LankyMySqlDb with the given configuration.
+// It establishes a connection to the MySQL database using the provided configuration parameters.
+// If the logger parameter is nil, a default logger instance will be created.
+// The isProduction parameter determines the log level for the connection.
+// The function returns a pointer to the LankyMySqlDb interface.
+func NewLankyMySql(conf llt.LankyMySqlConf, isProduction bool, logger *logrus.Logger) LankyMySqlDb {
+	if logger == nil {
+		logger = llog.NewInstance(
+			llog.SetServiceName("Lanky MySqlDB"),
+		)
+	}
+
+	logger.Info("🆕 Creating database connection...")
+
+	logLevel := glog.Info
+	if isProduction {
+		logLevel = glog.Warn
+	}
+
+	gormLogger := glog.New(
+		log.New(os.Stdout, "\r\n", log.LstdFlags),
+		glog.Config{
+			SlowThreshold:             conf.SlowSqlThreshold,
+			Colorful:                  true,
+			IgnoreRecordNotFoundError: true,
+			LogLevel:                  logLevel,
+		},
+	)
+
+	conf = withDefaults(conf)
+
+	dsn, err := buildDsn(conf)
+	if err != nil {
+		logger.Info("❌ Invalid database configuration")
+		logger.Fatal(err)
+	}
+
+	db, err := gorm.Open(
+		mysql.New(mysql.Config{
+			DSN: dsn,
+		}),
+		&gorm.Config{
+			Logger:                 gormLogger,
+			SkipDefaultTransaction: conf.SkipDefaultTransaction,
+		},
+	)
+	if err != nil {
+		logger.Infof("❌ Failed connecting to the database %s", llt.RedactDSN(dsn))
+		logger.Fatal(err)
+	}
+
+	sqlDb, err := db.DB()
+	if err != nil {
+		logger.Info("❌ Failed get the database")
+		logger.Fatal(err)
+	}
+
+	err = sqlDb.Ping()
+	if err != nil {
+		logger.Info("❌ Connection lost...")
+		logger.Fatal(err)
+	}
+
+	var (
+		maxIdleConnection = 5
+		maxOpenConnection = 10
+		connMaxLifeTime   = time.Hour
+	)
+
+	if conf.MaximumIdleConnection > 0 {
+		maxIdleConnection = conf.MaximumIdleConnection
+	}
+
+	if conf.MaximumOpenConnection > 0 {
+		maxOpenConnection = conf.MaximumOpenConnection
+	}
+
+	if conf.ConnectionMaxLifeTime > 0 {
+		connMaxLifeTime = conf.ConnectionMaxLifeTime
+	}
+
+	sqlDb.SetMaxIdleConns(maxIdleConnection)
+	sqlDb.SetMaxOpenConns(maxOpenConnection)
+	sqlDb.SetConnMaxLifetime(connMaxLifeTime)
+
+	logger.Infof(
+		"✅ Successfully connect to the database %s@%s:%s/%s",
+		conf.User,
+		conf.Host,
+		conf.Port,
+		conf.DbName,
+	)
+
+	return &mysqlDb{
+		db:    db,
+		sqlDb: sqlDb,
+		log:   logger,
+	}
+}
+
+// withDefaults fills the empty connection fields of the configuration with their default values.
+func withDefaults(conf llt.LankyMySqlConf) llt.LankyMySqlConf {
+	if conf.Host == "" {
+		conf.Host = "localhost"
+	}
+
+	if conf.User == "" {
+		conf.User = "root"
+	}
+
+	if conf.Port == "" {
+		conf.Port = "3306"
+	}
+
+	if conf.Charset == "" {
+		conf.Charset = "utf8mb4"
+	}
+
+	return conf
+}
+
+// buildDsn constructs the MySQL connection string from the connection fields of the configuration.
+// The DSN is formatted by the MySQL driver itself, so credentials are escaped properly.
+// Time values are always parsed into time.Time, in the configured location.
+func buildDsn(conf llt.LankyMySqlConf) (string, error) {
+	loc := time.Local
+	if conf.TimeZone != "" {
+		l, err := time.LoadLocation(conf.TimeZone)
+		if err != nil {
+			return "", err
+		}
+		loc = l
+	}
+
+	cfg := mysqldriver.NewConfig()
+	cfg.User = conf.User
+	cfg.Passwd = conf.Password
+	cfg.Net = "tcp"
+	cfg.Addr = net.JoinHostPort(conf.Host, conf.Port)
+	cfg.DBName = conf.DbName
+	cfg.ParseTime = true
+	cfg.Loc = loc
+	cfg.Params = map[string]string{"charset": conf.Charset}
+
+	return cfg.FormatDSN(), nil
+}
+
+func (m *mysqlDb) Db() *gorm.DB {
+	return m.db
+}
+
+func (m *mysqlDb) Sql() *sql.DB {
+	return m.sqlDb
+}
+
+func (m *mysqlDb) Close() error {
+	if !m.closed.CompareAndSwap(false, true) {
+		m.log.Info("ℹ️ Database connection already closed")
+		return nil
+	}
+
+	if err := m.Sql().Close(); err != nil {
+		m.closed.Store(false)
+		m.log.Info("❌ Failed to close connection database!")
+		m.log.Error(err)
+		return err
+	}
+
+	m.log.Info("✅ Success closing database connection...")
+	return nil
+}
+
+func (m *mysqlDb) CloseCtx(ctx context.Context) error {
+	if !m.closed.CompareAndSwap(false, true) {
+		m.log.Info("ℹ️ Database connection already closed")
+		return nil
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- m.Sql().Close()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			m.closed.Store(false)
+			m.log.Info("❌ Failed to close connection database!")
+			return err
+		}
+		m.log.Info("✅ Success closing database connection...")
+		return nil
+	case <-ctx.Done():
+		m.closed.Store(false)
+		m.log.Info("❌ Timed out closing connection database!")
+		return ctx.Err()
+	}
+}
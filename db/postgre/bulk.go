@@ -0,0 +1,40 @@
+package lanky_postgre
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// defaultBatchSize is the number of rows inserted per statement when the batch size is not positive.
+const defaultBatchSize = 100
+
+func (p *postgre) BulkInsert(ctx context.Context, value any, batchSize int) error {
+	rv := reflect.ValueOf(value)
+	for rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return fmt.Errorf("bulk insert expects a slice, got %T", value)
+	}
+
+	rows := rv.Len()
+	if rows == 0 {
+		p.log.Info("ℹ️ Nothing to bulk insert")
+		return nil
+	}
+
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	batches := (rows + batchSize - 1) / batchSize
+	name := modelName(rv.Index(0).Interface())
+
+	if err := p.db.WithContext(ctx).CreateInBatches(value, batchSize).Error; err != nil {
+		p.log.Infof("❌ Failed to bulk insert %d %s row(s) in %d batch(es)", rows, name, batches)
+		return fmt.Errorf("failed to bulk insert %s: %w", name, err)
+	}
+
+	p.log.Infof("✅ %d %s row(s) inserted in %d batch(es)", rows, name, batches)
+	return nil
+}
@@ -1,19 +1,25 @@
 package lanky_postgre
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"os"
+	"reflect"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/sirupsen/logrus"
 	llog "github.com/the-lanky/go/log"
 	llt "github.com/the-lanky/go/types"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	glog "gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
 )
 
 // LankyPostgreDb is an interface that represents a connection to a PostgreSQL database.
@@ -24,8 +30,32 @@ type LankyPostgreDb interface {
 	// Sql returns the underlying *sql.DB instance.
 	Sql() *sql.DB
 
-	// Close closes the database connection.
-	Close()
+	// Primary returns a *gorm.DB session that always runs against the primary database,
+	// even for read queries. Use it for reads that must observe a write made just before.
+	// Without read replicas it behaves exactly like Db.
+	Primary() *gorm.DB
+
+	// RegisterMetrics registers a collector exporting the connection pool statistics
+	// (open, in use and idle connections, wait count and wait duration) as Prometheus metrics,
+	// labeled with the given database name. The statistics are read on every scrape.
+	RegisterMetrics(reg prometheus.Registerer, dbName string) error
+
+	// Migrate runs GORM's AutoMigrate for each of the given models, logging every migrated model.
+	// It stops at the first failure and returns an error naming the model that failed.
+	Migrate(models ...any) error
+
+	// BulkInsert inserts the rows of the given slice with GORM's CreateInBatches, batchSize rows per statement
+	// (100 when not positive), logging the total rows and the number of batches. An empty slice is a no-op.
+	BulkInsert(ctx context.Context, value any, batchSize int) error
+
+	// Close closes the database connection and returns the error, letting the caller decide how to handle it.
+	// Close and CloseCtx are idempotent: once the connection is closed, further calls are a no-op.
+	// A failed close is not recorded, so it can be retried.
+	Close() error
+
+	// CloseCtx closes the database connection like Close.
+	// It stops waiting and returns the context error once the context is done, leaving the close running in the background.
+	CloseCtx(ctx context.Context) error
 }
 
 // postgre represents a PostgreSQL database connection.
@@ -33,12 +63,15 @@ type postgre struct {
 	db    *gorm.DB       // The GORM database connection.
 	sqlDb *sql.DB        // The SQL database connection.
 	log   *logrus.Logger // The logger instance for logging.
+
+	closed atomic.Bool // Whether Close or CloseCtx was called, reset when the close failed.
 }
 
 // NewLankyPostgre creates a new instance of LankyPostgreDb with the given configuration.
 // It establishes a connection to the PostgreSQL database using the provided configuration parameters.
 // If the logger parameter is nil, a default logger instance will be created.
 // The isProduction parameter determines the log level for the connection.
+// The plugins of the configuration are registered once connected, after the read replicas.
 // The function returns a pointer to the LankyPostgreDb interface.
 func NewLankyPostgre(conf llt.LankyPostgreConf, isProduction bool, logger *logrus.Logger) LankyPostgreDb {
 	if logger == nil {
@@ -54,52 +87,26 @@ func NewLankyPostgre(conf llt.LankyPostgreConf, isProduction bool, logger *logru
 		logLevel = glog.Warn
 	}
 
-	gormLogger := glog.New(
-		log.New(os.Stdout, "\r\n", log.LstdFlags),
-		glog.Config{
-			SlowThreshold:             conf.SlowSqlThreshold,
-			Colorful:                  true,
-			IgnoreRecordNotFoundError: true,
-			LogLevel:                  logLevel,
-		},
+	gormLogger := withSlowQueryCallback(
+		glog.New(
+			log.New(os.Stdout, "\r\n", log.LstdFlags),
+			glog.Config{
+				SlowThreshold:             conf.SlowSqlThreshold,
+				Colorful:                  true,
+				IgnoreRecordNotFoundError: true,
+				LogLevel:                  logLevel,
+			},
+		),
+		conf.SlowSqlThreshold,
+		conf.OnSlowQuery,
 	)
 
-	tmpDsn := make([]string, 0)
-
-	if conf.Host == "" {
-		conf.Host = "localhost"
-	}
-
-	if conf.User == "" {
-		conf.User = "postgres"
-	}
-
-	if conf.Port == "" {
-		conf.Port = "5432"
-	}
-
-	if conf.DbName == "" {
-		conf.DbName = "postgres"
-	}
-
-	tmpDsn = append(tmpDsn, fmt.Sprintf("host=%s", conf.Host))
-	tmpDsn = append(tmpDsn, fmt.Sprintf("user=%s", conf.User))
-	tmpDsn = append(tmpDsn, fmt.Sprintf("port=%s", conf.Port))
-	tmpDsn = append(tmpDsn, fmt.Sprintf("dbname=%s", conf.DbName))
-
-	if conf.Password != "" {
-		tmpDsn = append(tmpDsn, fmt.Sprintf("password=%s", conf.Password))
-	}
-
-	if conf.SslMode != "" {
-		tmpDsn = append(tmpDsn, fmt.Sprintf("sslmode=%s", conf.SslMode))
-	}
-
-	if conf.TimeZone != "" {
-		tmpDsn = append(tmpDsn, fmt.Sprintf("TimeZone=%s", conf.TimeZone))
+	if conf.AppName == "" {
+		conf.AppName = llog.ServiceName(logger)
 	}
 
-	dsn := strings.Join(tmpDsn, " ")
+	conf = withDefaults(conf)
+	dsn := buildDsn(conf)
 
 	db, err := gorm.Open(
 		postgres.New(postgres.Config{
@@ -108,22 +115,11 @@ func NewLankyPostgre(conf llt.LankyPostgreConf, isProduction bool, logger *logru
 		&gorm.Config{
 			Logger:                 gormLogger,
 			SkipDefaultTransaction: conf.SkipDefaultTransaction,
+			PrepareStmt:            conf.PrepareStmt,
 		},
 	)
 	if err != nil {
-		logger.Info("❌ Failed connecting to the database")
-		logger.Fatal(err)
-	}
-
-	sqlDb, err := db.DB()
-	if err != nil {
-		logger.Info("❌ Failed get the database")
-		logger.Fatal(err)
-	}
-
-	err = sqlDb.Ping()
-	if err != nil {
-		logger.Info("❌ Connection lost...")
+		logger.Infof("❌ Failed connecting to the database %s", llt.RedactDSN(dsn))
 		logger.Fatal(err)
 	}
 
@@ -145,10 +141,63 @@ func NewLankyPostgre(conf llt.LankyPostgreConf, isProduction bool, logger *logru
 		connMaxLifeTime = conf.ConnectionMaxLifeTime
 	}
 
+	if len(conf.ReadReplicas) > 0 {
+		replicas := make([]gorm.Dialector, 0, len(conf.ReadReplicas))
+		for _, replica := range conf.ReadReplicas {
+			replica = withDefaults(replica)
+			if replica.AppName == "" {
+				replica.AppName = conf.AppName
+			}
+			replicas = append(replicas, postgres.New(postgres.Config{
+				DSN: buildDsn(replica),
+			}))
+			logger.Infof("🔀 Registering read replica %s@%s:%s/%s", replica.User, replica.Host, replica.Port, replica.DbName)
+		}
+
+		// The pools of the replicas are sized like the pool of the primary.
+		resolver := dbresolver.Register(dbresolver.Config{
+			Replicas: replicas,
+			Policy:   dbresolver.RandomPolicy{},
+		}).
+			SetMaxIdleConns(maxIdleConnection).
+			SetMaxOpenConns(maxOpenConnection).
+			SetConnMaxLifetime(connMaxLifeTime)
+
+		if err = db.Use(resolver); err != nil {
+			logger.Info("❌ Failed registering the read replicas")
+			logger.Fatal(err)
+		}
+	}
+
+	for _, plugin := range conf.Plugins {
+		if err = db.Use(plugin); err != nil {
+			logger.Infof("❌ Failed registering the plugin %s", plugin.Name())
+			logger.Fatal(err)
+		}
+		logger.Infof("🔌 Plugin %s registered", plugin.Name())
+	}
+
+	sqlDb, err := db.DB()
+	if err != nil {
+		logger.Info("❌ Failed get the database")
+		logger.Fatal(err)
+	}
+
+	err = sqlDb.Ping()
+	if err != nil {
+		logger.Info("❌ Connection lost...")
+		logger.Fatal(err)
+	}
+
 	sqlDb.SetMaxIdleConns(maxIdleConnection)
 	sqlDb.SetMaxOpenConns(maxOpenConnection)
 	sqlDb.SetConnMaxLifetime(connMaxLifeTime)
 
+	if conf.WarmUpConnections > 0 {
+		warmed := warmUp(sqlDb, conf.WarmUpConnections, maxIdleConnection)
+		logger.Infof("🔥 Warmed up %d/%d connection(s)", warmed, conf.WarmUpConnections)
+	}
+
 	logger.Infof(
 		"✅ Successfully connect to the database %s@%s:%s/%s",
 		conf.User,
@@ -157,11 +206,76 @@ func NewLankyPostgre(conf llt.LankyPostgreConf, isProduction bool, logger *logru
 		conf.DbName,
 	)
 
-	return &postgre{
+	p := &postgre{
 		db:    db,
 		sqlDb: sqlDb,
 		log:   logger,
 	}
+
+	if conf.AutoMigrate {
+		if err := p.Migrate(conf.MigrationModels...); err != nil {
+			logger.Fatal(err)
+		}
+	}
+
+	return p
+}
+
+// withDefaults fills the empty connection fields of the configuration with their default values.
+func withDefaults(conf llt.LankyPostgreConf) llt.LankyPostgreConf {
+	if conf.Host == "" {
+		conf.Host = "localhost"
+	}
+
+	if conf.User == "" {
+		conf.User = "postgres"
+	}
+
+	if conf.Port == "" {
+		conf.Port = "5432"
+	}
+
+	if conf.DbName == "" {
+		conf.DbName = "postgres"
+	}
+
+	return conf
+}
+
+// buildDsn constructs the PostgreSQL connection string from the connection fields of the configuration.
+// Optional fields like password, sslmode, timezone and application name are only added when they are set.
+func buildDsn(conf llt.LankyPostgreConf) string {
+	tmpDsn := make([]string, 0)
+
+	tmpDsn = append(tmpDsn, fmt.Sprintf("host=%s", conf.Host))
+	tmpDsn = append(tmpDsn, fmt.Sprintf("user=%s", conf.User))
+	tmpDsn = append(tmpDsn, fmt.Sprintf("port=%s", conf.Port))
+	tmpDsn = append(tmpDsn, fmt.Sprintf("dbname=%s", conf.DbName))
+
+	if conf.Password != "" {
+		tmpDsn = append(tmpDsn, fmt.Sprintf("password=%s", conf.Password))
+	}
+
+	if conf.SslMode != "" {
+		tmpDsn = append(tmpDsn, fmt.Sprintf("sslmode=%s", conf.SslMode))
+	}
+
+	if conf.TimeZone != "" {
+		tmpDsn = append(tmpDsn, fmt.Sprintf("TimeZone=%s", conf.TimeZone))
+	}
+
+	if conf.AppName != "" {
+		tmpDsn = append(tmpDsn, fmt.Sprintf("application_name=%s", quoteDsnValue(conf.AppName)))
+	}
+
+	return strings.Join(tmpDsn, " ")
+}
+
+// quoteDsnValue quotes the value of a keyword/value DSN, so it may hold spaces, e.g. a service name.
+func quoteDsnValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
 }
 
 func (p *postgre) Db() *gorm.DB {
@@ -172,11 +286,93 @@ func (p *postgre) Sql() *sql.DB {
 	return p.sqlDb
 }
 
-func (p *postgre) Close() {
+func (p *postgre) Primary() *gorm.DB {
+	return p.db.Clauses(dbresolver.Write)
+}
+
+func (p *postgre) RegisterMetrics(reg prometheus.Registerer, dbName string) error {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+
+	if err := reg.Register(collectors.NewDBStatsCollector(p.sqlDb, dbName)); err != nil {
+		p.log.Info("❌ Failed to register the database metrics")
+		return err
+	}
+
+	p.log.Infof("📊 Database metrics registered for %s", dbName)
+	return nil
+}
+
+func (p *postgre) Migrate(models ...any) error {
+	for _, model := range models {
+		name := modelName(model)
+
+		if err := p.db.AutoMigrate(model); err != nil {
+			p.log.Infof("❌ Failed to migrate model %s", name)
+			return fmt.Errorf("failed to migrate model %s: %w", name, err)
+		}
+
+		p.log.Infof("✅ Model %s migrated", name)
+	}
+
+	return nil
+}
+
+// modelName returns the name of the model type, dereferencing pointers.
+func modelName(model any) string {
+	t := reflect.TypeOf(model)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == nil {
+		return "<nil>"
+	}
+
+	return t.String()
+}
+
+func (p *postgre) Close() error {
+	if !p.closed.CompareAndSwap(false, true) {
+		p.log.Info("ℹ️ Database connection already closed")
+		return nil
+	}
+
 	if err := p.Sql().Close(); err != nil {
+		p.closed.Store(false)
 		p.log.Info("❌ Failed to close connection database!")
-		p.log.Fatal(err)
-	} else {
+		p.log.Error(err)
+		return err
+	}
+
+	p.log.Info("✅ Success closing database connection...")
+	return nil
+}
+
+func (p *postgre) CloseCtx(ctx context.Context) error {
+	if !p.closed.CompareAndSwap(false, true) {
+		p.log.Info("ℹ️ Database connection already closed")
+		return nil
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- p.Sql().Close()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			p.closed.Store(false)
+			p.log.Info("❌ Failed to close connection database!")
+			return err
+		}
 		p.log.Info("✅ Success closing database connection...")
+		return nil
+	case <-ctx.Done():
+		p.closed.Store(false)
+		p.log.Info("❌ Timed out closing connection database!")
+		return ctx.Err()
 	}
 }
@@ -0,0 +1,49 @@
+package lanky_postgre
+
+import (
+	"errors"
+
+	lerr "github.com/the-lanky/go/errors"
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned by NormalizeError instead of gorm.ErrRecordNotFound, so callers do not depend on GORM.
+var ErrNotFound = errors.New("lanky postgre: record not found")
+
+// IsNotFound reports whether the error is, or wraps, gorm.ErrRecordNotFound or ErrNotFound.
+//
+// Example usage:
+//
+//	err := db.Db().WithContext(ctx).First(&user, id).Error
+//	if lanky_postgre.IsNotFound(err) {
+//	    // ...
+//	}
+func IsNotFound(err error) bool {
+	return errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, ErrNotFound)
+}
+
+// NormalizeError converts gorm.ErrRecordNotFound into ErrNotFound. Any other error, or nil, is returned as is.
+func NormalizeError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// NotFoundError converts a not found error, see IsNotFound, into the *LankyCommonError registered for the code,
+// e.g. a code mapped to a 404 status. Any other error, or nil, is returned as is.
+//
+// Example usage:
+//
+//	err := db.Db().WithContext(ctx).First(&user, id).Error
+//	if err = lanky_postgre.NotFoundError(err, ErrUserNotFound); err != nil {
+//	    lanky_server.WriteError(w, err)
+//	    return
+//	}
+func NotFoundError(err error, code lerr.LankyErrorCode) error {
+	if !IsNotFound(err) {
+		return err
+	}
+
+	return lerr.New(code, ErrNotFound)
+}
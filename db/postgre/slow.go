@@ -0,0 +1,43 @@
+package lanky_postgre
+
+import (
+	"context"
+	"time"
+
+	glog "gorm.io/gorm/logger"
+)
+
+// slowQueryLogger wraps the GORM logger to invoke a callback for every query slower than the threshold,
+// on top of the regular logging of the wrapped logger.
+type slowQueryLogger struct {
+	glog.Interface
+	threshold time.Duration
+	onSlow    func(sql string, duration time.Duration, rows int64)
+}
+
+// withSlowQueryCallback returns the logger wrapped with the callback, or the logger itself
+// when there is no callback or no threshold.
+func withSlowQueryCallback(
+	logger glog.Interface,
+	threshold time.Duration,
+	onSlow func(sql string, duration time.Duration, rows int64),
+) glog.Interface {
+	if onSlow == nil || threshold <= 0 {
+		return logger
+	}
+	return &slowQueryLogger{Interface: logger, threshold: threshold, onSlow: onSlow}
+}
+
+// LogMode keeps the callback when GORM derives a logger with another level, e.g. in db.Debug().
+func (l *slowQueryLogger) LogMode(level glog.LogLevel) glog.Interface {
+	return &slowQueryLogger{Interface: l.Interface.LogMode(level), threshold: l.threshold, onSlow: l.onSlow}
+}
+
+func (l *slowQueryLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	l.Interface.Trace(ctx, begin, fc, err)
+
+	if elapsed := time.Since(begin); elapsed > l.threshold {
+		sql, rows := fc()
+		l.onSlow(sql, elapsed, rows)
+	}
+}
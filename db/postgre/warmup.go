@@ -0,0 +1,57 @@
+package lanky_postgre
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// warmUpTimeout bounds the warm-up of the connection pool.
+const warmUpTimeout = time.Second * 30
+
+// warmUp opens n connections concurrently, running SELECT 1 on each, then releases them all at once
+// so they stay idle in the pool. It returns the number of connections warmed.
+// Only up to maxIdle connections are kept by the pool, so n is capped to it.
+func warmUp(sqlDb *sql.DB, n int, maxIdle int) int {
+	if n > maxIdle {
+		n = maxIdle
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), warmUpTimeout)
+	defer cancel()
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		conns = make([]*sql.Conn, 0, n)
+	)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			conn, err := sqlDb.Conn(ctx)
+			if err != nil {
+				return
+			}
+			if _, err := conn.ExecContext(ctx, "SELECT 1"); err != nil {
+				conn.Close()
+				return
+			}
+
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	// The connections are held until all of them are opened, so the pool cannot hand the same one twice.
+	for _, conn := range conns {
+		conn.Close()
+	}
+
+	return len(conns)
+}
@@ -1,6 +1,9 @@
 package lanky_errors
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 )
@@ -54,6 +57,106 @@ func (lce *LankyHttpCommonError) Error() string {
 	return fmt.Sprintf("HttpErr: %+v\nHttpTrace: %+v", lce.Err, lce.Trace)
 }
 
+// codeFormat is the format of the string representation of a LankyErrorCode, e.g. E0042.
+const codeFormat = "E%04d"
+
+// stringCode indicates whether LankyCommonError marshals its code as a string, see SetStringCode.
+var stringCode = false
+
+// SetStringCode sets whether LankyCommonError marshals its code as a zero-padded string (e.g. "E0042")
+// instead of a number. It is disabled by default to keep the existing JSON shape.
+// LankyHttpCommonError always marshals its code as a string.
+func SetStringCode(enabled bool) {
+	stringCode = enabled
+}
+
+// jsonKeys holds the JSON keys of the fields of the marshaled errors, see SetJSONKeys.
+var jsonKeys = struct {
+	code, message, data string
+}{
+	code:    "code",
+	message: "message",
+	data:    "data",
+}
+
+// SetJSONKeys sets the JSON keys of the code, the client message and the system message of the marshaled errors,
+// e.g. SetJSONKeys("error_code", "error_message", "details"). An empty key keeps the current one.
+// The defaults are "code", "message" and "data".
+func SetJSONKeys(codeKey, messageKey, dataKey string) {
+	if len(codeKey) > 0 {
+		jsonKeys.code = codeKey
+	}
+	if len(messageKey) > 0 {
+		jsonKeys.message = messageKey
+	}
+	if len(dataKey) > 0 {
+		jsonKeys.data = dataKey
+	}
+}
+
+// jsonField is a key and its value, marshaled in order by marshalError.
+type jsonField struct {
+	key   string
+	value any
+}
+
+// marshalError marshals the fields of an error with the configured JSON keys, in the order message, data, code,
+// followed by the status when it is not zero.
+func marshalError(message string, data any, code any, status int) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+
+	fields := []jsonField{
+		{jsonKeys.message, message},
+		{jsonKeys.data, data},
+		{jsonKeys.code, code},
+	}
+	if status != 0 {
+		fields = append(fields, jsonField{"status", status})
+	}
+
+	for i, field := range fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, err := json.Marshal(field.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// MarshalJSON marshals the LankyCommonError with a numeric code,
+// or with a zero-padded string code when SetStringCode is enabled, under the keys set by SetJSONKeys.
+func (lce LankyCommonError) MarshalJSON() ([]byte, error) {
+	var code any = lce.Code
+	if stringCode {
+		code = fmt.Sprintf(codeFormat, lce.Code)
+	}
+
+	return marshalError(lce.ClientMessage, lce.SystemMessage, code, 0)
+}
+
+// MarshalJSON marshals the LankyHttpCommonError with a zero-padded string code and its HTTP status,
+// under the keys set by SetJSONKeys, e.g.
+//
+//	{"message":"Not found","data":"record not found","code":"E0042","status":404}
+func (lce LankyHttpCommonError) MarshalJSON() ([]byte, error) {
+	return marshalError(lce.ClientMessage, lce.SystemMessage, fmt.Sprintf(codeFormat, lce.Code), lce.HttpStatusNumber)
+}
+
 // UnidentifiedError represents an unidentified error in the Lanky library.
 const UnidentifiedError LankyErrorCode = 0
 
@@ -83,6 +186,12 @@ func Register(dict map[LankyErrorCode]*LankyCommonError, stat map[LankyErrorCode
 		stat: stat,
 	}
 
+	registerUnidentified()
+}
+
+// registerUnidentified adds the entry of the UnidentifiedError code in both the dictionary and statistics map
+// of the registry, with the error for internal server error.
+func registerUnidentified() {
 	me.dict[UnidentifiedError] = &LankyCommonError{
 		ClientMessage: "Unidentified error has occured. Please contact our dev",
 		SystemMessage: "Internal server error",
@@ -92,6 +201,98 @@ func Register(dict map[LankyErrorCode]*LankyCommonError, stat map[LankyErrorCode
 	me.stat[UnidentifiedError] = http.StatusInternalServerError
 }
 
+// RegisterMerge adds the given dictionary of Lanky error codes and their HTTP status codes to the existing registry,
+// instead of replacing it like Register does, so several packages can each register their own error catalog.
+// It fails without registering anything if any of the codes is already registered, including UnidentifiedError,
+// and returns an error listing all the duplicated codes.
+func RegisterMerge(dict map[LankyErrorCode]*LankyCommonError, stat map[LankyErrorCode]int) error {
+	if _, ok := me.dict[UnidentifiedError]; !ok {
+		registerUnidentified()
+	}
+
+	var errs []error
+	for code := range dict {
+		if _, ok := me.dict[code]; ok {
+			errs = append(errs, fmt.Errorf("error code %d is already registered", code))
+		}
+	}
+	for code := range stat {
+		if _, ok := me.stat[code]; ok {
+			if _, inDict := dict[code]; !inDict {
+				errs = append(errs, fmt.Errorf("error code %d already has an http status registered", code))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	for code, lce := range dict {
+		me.dict[code] = lce
+	}
+	for code, st := range stat {
+		me.stat[code] = st
+	}
+
+	return nil
+}
+
+// ResetRegistry reinitializes the registry to an empty state holding only the UnidentifiedError default.
+// It is intended for tests, to restore a clean registry between test cases.
+func ResetRegistry() {
+	me = &mapError{
+		dict: make(map[LankyErrorCode]*LankyCommonError),
+		stat: make(map[LankyErrorCode]int),
+	}
+
+	registerUnidentified()
+}
+
+// RegistrySnapshot is a copy of the registry taken by Snapshot.
+type RegistrySnapshot struct {
+	dict map[LankyErrorCode]*LankyCommonError
+	stat map[LankyErrorCode]int
+}
+
+// Snapshot returns a copy of the current registry that can be reinstated with Restore.
+// It is intended for tests, e.g.:
+//
+//	snap := lanky_errors.Snapshot()
+//	defer lanky_errors.Restore(snap)
+func Snapshot() RegistrySnapshot {
+	snap := RegistrySnapshot{
+		dict: make(map[LankyErrorCode]*LankyCommonError, len(me.dict)),
+		stat: make(map[LankyErrorCode]int, len(me.stat)),
+	}
+
+	for code, lce := range me.dict {
+		snap.dict[code] = lce
+	}
+	for code, st := range me.stat {
+		snap.stat[code] = st
+	}
+
+	return snap
+}
+
+// Restore reinstates the registry from a snapshot taken by Snapshot.
+// It is intended for tests.
+func Restore(snap RegistrySnapshot) {
+	restored := &mapError{
+		dict: make(map[LankyErrorCode]*LankyCommonError, len(snap.dict)),
+		stat: make(map[LankyErrorCode]int, len(snap.stat)),
+	}
+
+	for code, lce := range snap.dict {
+		restored.dict[code] = lce
+	}
+	for code, st := range snap.stat {
+		restored.stat[code] = st
+	}
+
+	me = restored
+}
+
 // GetHttpStatus returns the HTTP status code associated with the LankyCommonError.
 // If the status code is not found in the internal map, it returns http.StatusInternalServerError.
 func (lce *LankyCommonError) GetHttpStatus() int {
@@ -102,6 +303,38 @@ func (lce *LankyCommonError) GetHttpStatus() int {
 	}
 }
 
+// ErrorResponse is the client-facing body of a LankyCommonError returned by Response.
+// Code holds the numeric LankyErrorCode, or its zero-padded string when SetStringCode is enabled.
+// It is marshaled under the keys set by SetJSONKeys.
+type ErrorResponse struct {
+	Message string `json:"message"`
+	Data    any    `json:"data"`
+	Code    any    `json:"code"`
+}
+
+// MarshalJSON marshals the ErrorResponse under the keys set by SetJSONKeys.
+func (er ErrorResponse) MarshalJSON() ([]byte, error) {
+	return marshalError(er.Message, er.Data, er.Code, 0)
+}
+
+// Response returns the HTTP status of the LankyCommonError, see GetHttpStatus, and its client-facing body,
+// so any HTTP framework can write it with its own JSON writer, e.g.
+//
+//	status, body := lce.Response()
+//	c.JSON(status, body)
+func (lce *LankyCommonError) Response() (int, any) {
+	var code any = lce.Code
+	if stringCode {
+		code = fmt.Sprintf(codeFormat, lce.Code)
+	}
+
+	return lce.GetHttpStatus(), ErrorResponse{
+		Message: lce.ClientMessage,
+		Data:    lce.SystemMessage,
+		Code:    code,
+	}
+}
+
 // ToHttpStatusError converts a LankyCommonError to a LankyHttpCommonError with the corresponding HTTP status number.
 // It returns a pointer to the converted LankyHttpCommonError.
 func (lce *LankyCommonError) ToHttpStatusError() *LankyHttpCommonError {
@@ -113,6 +346,7 @@ func (lce *LankyCommonError) ToHttpStatusError() *LankyHttpCommonError {
 
 // New creates a new instance of LankyCommonError with the given error code and error.
 // It returns a pointer to the created LankyCommonError.
+// The registered error of the code is copied, never modified, so New is safe for concurrent use.
 // If the error is not nil, it sets the error message and error trace in the LankyCommonError.
 // If the error code is UnidentifiedError, it sets the client message and system message to the error message and error trace respectively.
 // If the error is already an instance of LankyCommonError, it returns the error as is.
@@ -149,8 +383,11 @@ func New(code LankyErrorCode, err error) *LankyCommonError {
 			Trace:         et,
 		}
 	} else {
-		lce.Err = em
-		lce.Trace = et
+		// The registered error is shared by every caller, it is copied before setting the error of this call.
+		copied := *lce
+		copied.Err = em
+		copied.Trace = et
+		lce = &copied
 	}
 
 	if lce2, ok := err.(*LankyCommonError); ok {
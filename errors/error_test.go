@@ -0,0 +1,41 @@
+package lanky_errors
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestNewDoesNotModifyTheRegisteredError(t *testing.T) {
+	Register(map[LankyErrorCode]*LankyCommonError{}, map[LankyErrorCode]int{})
+
+	registered := me.dict[UnidentifiedError]
+	before := *registered
+
+	lce := New(UnidentifiedError, errors.New("boom"))
+	if lce == registered {
+		t.Fatal("expected New to return a copy of the registered error")
+	}
+	if lce.Err == nil || *lce.Err != "boom" {
+		t.Fatalf("expected the error of the call to be set, got %v", lce.Err)
+	}
+	if registered.Err != before.Err || registered.Trace != before.Trace {
+		t.Fatal("expected the registered error to be left untouched")
+	}
+}
+
+func TestNewConcurrentCalls(t *testing.T) {
+	Register(map[LankyErrorCode]*LankyCommonError{}, map[LankyErrorCode]int{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, body := New(UnidentifiedError, nil).Response(); body == nil {
+				t.Error("expected a response body")
+			}
+		}()
+	}
+	wg.Wait()
+}
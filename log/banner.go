@@ -0,0 +1,53 @@
+package lanky_logger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// BannerTitle is the first line of the summary logged by Banner.
+const BannerTitle = "[🚀] Boot summary"
+
+// Banner logs the given entries as a single aligned block at Info level, sorted by key, e.g. the effective
+// configuration of the components a service connected to on boot. The entries are also attached as fields,
+// so the JSON output stays greppable. Secrets must be redacted by the caller.
+// If the logger is nil, the logrus standard logger is used.
+//
+// Example usage:
+//
+//	lanky_logger.Banner(log, map[string]string{
+//	    "postgres":  "app@db.internal:5432/orders",
+//	    "rabbitmq":  "exchange orders (topic), queue orders.api",
+//	    "server":    "http://0.0.0.0:8080",
+//	})
+func Banner(log *logrus.Logger, entries map[string]string) {
+	if log == nil {
+		log = logrus.StandardLogger()
+	}
+
+	keys := make([]string, 0, len(entries))
+	width := 0
+	for key := range entries {
+		keys = append(keys, key)
+		if len(key) > width {
+			width = len(key)
+		}
+	}
+	sort.Strings(keys)
+
+	var (
+		b      strings.Builder
+		fields = make(logrus.Fields, len(entries))
+	)
+
+	b.WriteString(BannerTitle)
+	for _, key := range keys {
+		fmt.Fprintf(&b, "\n  %-*s : %s", width, key, entries[key])
+		fields[key] = entries[key]
+	}
+
+	log.WithFields(fields).Info(b.String())
+}
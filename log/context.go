@@ -0,0 +1,102 @@
+package lanky_logger
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RequestIDField is the name of the log field holding the request ID.
+const RequestIDField = "request_id"
+
+// contextKey is the type of the context keys of this package, so they never collide with other packages.
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	fieldsKey
+	loggerKey
+)
+
+// ContextWithRequestID returns a copy of the context carrying the given request ID.
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestIDFromContext returns the request ID carried by the context, or an empty string if there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithRequestID returns a log entry bound to the context with its request ID attached as a field.
+// The entry belongs to the logger carried by the context (see ContextWithLogger), or the logrus standard logger if there is none.
+// The requests of a lanky_server carry the logger of the server.
+//
+// Example usage:
+//
+//	lanky_logger.WithRequestID(r.Context()).Info("Order created")
+func WithRequestID(ctx context.Context) *logrus.Entry {
+	entry := loggerFromContext(ctx).WithContext(ctx)
+	if id := RequestIDFromContext(ctx); len(id) > 0 {
+		entry = entry.WithField(RequestIDField, id)
+	}
+
+	return entry
+}
+
+// ContextWithFields returns a copy of the context carrying the given fields, merged over the fields
+// already carried by the context. The loggers built by NewInstance attach them to every entry logged
+// with that context, e.g. to scope a user or tenant ID to a request:
+//
+//	ctx = lanky_logger.ContextWithFields(r.Context(), logrus.Fields{"user_id": userID})
+//	log.WithContext(ctx).Info("Order created")
+func ContextWithFields(ctx context.Context, fields logrus.Fields) context.Context {
+	merged := make(logrus.Fields, len(fields))
+	for k, v := range FieldsFromContext(ctx) {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, fieldsKey, merged)
+}
+
+// FieldsFromContext returns the fields carried by the context, or nil if there are none.
+// The returned fields must not be modified.
+func FieldsFromContext(ctx context.Context) logrus.Fields {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsKey).(logrus.Fields)
+	return fields
+}
+
+// ContextWithLogger returns a copy of the context carrying the given logger, used by FromContext.
+func ContextWithLogger(ctx context.Context, log *logrus.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, log)
+}
+
+// FromContext returns a log entry bound to the context, with the request ID and the fields of the context attached.
+// The entry belongs to the logger carried by the context (see ContextWithLogger), or the logrus standard logger if there is none,
+// so handlers can log without the logger being threaded through every call.
+//
+// Example usage:
+//
+//	lanky_logger.FromContext(r.Context()).Info("Order created")
+func FromContext(ctx context.Context) *logrus.Entry {
+	return WithRequestID(ctx).WithFields(FieldsFromContext(ctx))
+}
+
+// loggerFromContext returns the logger carried by the context, or the logrus standard logger if there is none.
+func loggerFromContext(ctx context.Context) *logrus.Logger {
+	if ctx != nil {
+		if log, ok := ctx.Value(loggerKey).(*logrus.Logger); ok && log != nil {
+			return log
+		}
+	}
+	return logrus.StandardLogger()
+}
@@ -0,0 +1,52 @@
+package lanky_logger
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestWithRequestID(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		wantLogger *logrus.Logger
+		wantID     any
+	}{
+		{
+			name:       "logger and request ID of the context",
+			ctx:        ContextWithRequestID(ContextWithLogger(context.Background(), log), "req-1"),
+			wantLogger: log,
+			wantID:     "req-1",
+		},
+		{
+			name:       "standard logger without a logger in the context",
+			ctx:        ContextWithRequestID(context.Background(), "req-2"),
+			wantLogger: logrus.StandardLogger(),
+			wantID:     "req-2",
+		},
+		{
+			name:       "no request ID",
+			ctx:        ContextWithLogger(context.Background(), log),
+			wantLogger: log,
+			wantID:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := WithRequestID(tt.ctx)
+			if entry.Logger != tt.wantLogger {
+				t.Errorf("expected the entry to belong to %p, got %p", tt.wantLogger, entry.Logger)
+			}
+			if got := entry.Data[RequestIDField]; got != tt.wantID {
+				t.Errorf("%s = %v, want %v", RequestIDField, got, tt.wantID)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package lanky_logger
+
+import (
+	"io"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// fileOutput holds the settings of the rotating log file.
+type fileOutput struct {
+	path       string
+	maxSizeMB  int
+	maxBackups int
+	maxAgeDays int
+}
+
+// fileHook writes every entry to a rotating file with its own formatter,
+// so the file gets plain JSON lines while stdout keeps the colored text output.
+type fileHook struct {
+	mu        sync.Mutex
+	writer    io.Writer
+	formatter logrus.Formatter
+}
+
+func newFileHook(fo *fileOutput, formatter logrus.Formatter) *fileHook {
+	return &fileHook{
+		writer: &lumberjack.Logger{
+			Filename:   fo.path,
+			MaxSize:    fo.maxSizeMB,
+			MaxBackups: fo.maxBackups,
+			MaxAge:     fo.maxAgeDays,
+		},
+		formatter: formatter,
+	}
+}
+
+func (fh *fileHook) Fire(entry *logrus.Entry) error {
+	serialized, err := fh.formatter.Format(entry)
+	if err != nil || len(serialized) == 0 {
+		return err
+	}
+
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+
+	_, err = fh.writer.Write(serialized)
+	return err
+}
+
+func (fh *fileHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
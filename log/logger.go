@@ -1,17 +1,34 @@
 package lanky_logger
 
 import (
+	"os"
+	"time"
+
 	"github.com/mattn/go-colorable"
+	"github.com/mattn/go-isatty"
 	"github.com/sirupsen/logrus"
 )
 
 // config represents the configuration for the logger.
 type config struct {
-	isProduction     bool           // indicates whether the logger is running in production mode
-	serviceName      string         // the name of the service using the logger
-	additionalFields map[string]any // additional fields to include in the log messages
+	isProduction     bool                // indicates whether the logger is running in production mode
+	serviceName      string              // the name of the service using the logger
+	additionalFields map[string]any      // additional fields to include in the log messages
+	sampling         int                 // emit only every Nth Info, Debug and Trace entry; 0 or 1 disables sampling
+	errorReporter    func(*logrus.Entry) // invoked for every entry at Error level and above
+	fileOutput       *fileOutput         // the rotating file the logs are also written to, if any
+	forceColor       *bool               // overrides the terminal detection of colored output when set
+	jsonFormat       bool                // formats the stdout output as JSON instead of text
+	timeFormat       string              // the layout of the timestamps, RFC3339 when empty
+	utc              bool                // formats the timestamps in UTC instead of the local time zone
 }
 
+// ServiceField is the name of the log field holding the service name.
+const ServiceField = "service"
+
+// TimestampField is the name of the field holding the RFC3339 timestamp of the JSON formatted entries.
+const TimestampField = "timestamp"
+
 // Option is a function type that represents an option for configuring the logger.
 // It takes a pointer to a config struct and modifies its properties.
 type Option func(o *config)
@@ -49,11 +66,118 @@ func SetFields(fields map[string]any) Option {
 	}
 }
 
+// SetSampling enables sampling of the high-volume levels.
+// Only every Nth entry at Info, Debug and Trace level is emitted (the 1st, the N+1th, ...),
+// while Warn and above always pass through. A value of 0 or 1 disables sampling.
+// It is meant for hot paths, such as the per-message logs of RabbitMQ publish and consume.
+func SetSampling(n int) Option {
+	return func(o *config) {
+		o.sampling = n
+	}
+}
+
+// SetErrorReporter registers a function invoked with every entry logged at Error level and above,
+// giving access to its message, fields and error (under logrus.ErrorKey).
+// It decouples the logger from any error reporting SDK, e.g.:
+//
+//	logger.SetErrorReporter(func(entry *logrus.Entry) {
+//	  sentry.CaptureMessage(entry.Message)
+//	})
+//
+// A panic in the reporter is recovered so it never breaks the logging path.
+func SetErrorReporter(fn func(entry *logrus.Entry)) Option {
+	return func(o *config) {
+		o.errorReporter = fn
+	}
+}
+
+// SetFileOutput writes the logs to a rotating file in addition to stdout.
+// The file is rotated once it reaches maxSizeMB megabytes, keeping at most maxBackups old files
+// for at most maxAgeDays days (0 keeps them all). The file copy is formatted as JSON, without ANSI color codes.
+//
+// Example usage:
+//
+//	logger.SetFileOutput("/var/log/my-service.log", 100, 5, 7)
+func SetFileOutput(path string, maxSizeMB, maxBackups, maxAgeDays int) Option {
+	return func(o *config) {
+		o.fileOutput = &fileOutput{
+			path:       path,
+			maxSizeMB:  maxSizeMB,
+			maxBackups: maxBackups,
+			maxAgeDays: maxAgeDays,
+		}
+	}
+}
+
+// SetForceColor overrides the terminal detection of colored output.
+// By default the output is colored only when stdout is a terminal, so logs captured
+// by a file, journald or a container platform do not contain escape codes.
+// Passing true forces colors regardless, passing false always disables them.
+func SetForceColor(forceColor bool) Option {
+	return func(o *config) {
+		o.forceColor = &forceColor
+	}
+}
+
+// SetJSONFormat formats the stdout output as JSON instead of text, e.g. for log collectors.
+// Like the file output, JSON entries carry their time as an RFC3339 timestamp field, and are never colored.
+func SetJSONFormat(jsonFormat bool) Option {
+	return func(o *config) {
+		o.jsonFormat = jsonFormat
+	}
+}
+
+// SetTimeFormat sets the layout of the timestamps of the text, JSON and file outputs, e.g. time.RFC3339Nano.
+// The text output then always prints the full timestamp, like with SetUTC. Defaults to time.RFC3339.
+func SetTimeFormat(layout string) Option {
+	return func(o *config) {
+		o.timeFormat = layout
+	}
+}
+
+// SetUTC formats the timestamps of the text, JSON and file outputs in UTC instead of the local time zone,
+// so the logs of hosts in different time zones can be correlated.
+func SetUTC(utc bool) Option {
+	return func(o *config) {
+		o.utc = utc
+	}
+}
+
+// newJSONFormatter creates the formatter of the JSON outputs, with the time as a timestamp field
+// formatted with the given layout, RFC3339 when empty.
+func newJSONFormatter(layout string) logrus.Formatter {
+	if len(layout) == 0 {
+		layout = time.RFC3339
+	}
+
+	return &logrus.JSONFormatter{
+		TimestampFormat: layout,
+		FieldMap:        logrus.FieldMap{logrus.FieldKeyTime: TimestampField},
+	}
+}
+
+// utcFormatter formats the entries with their time converted to UTC.
+type utcFormatter struct {
+	logrus.Formatter
+}
+
+func (f *utcFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	utc := *entry
+	utc.Time = entry.Time.UTC()
+	return f.Formatter.Format(&utc)
+}
+
+// isTerminal reports whether stdout is attached to a terminal.
+func isTerminal() bool {
+	fd := os.Stdout.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
 // NewInstance creates a new instance of the logrus.Logger with the provided options.
 // It accepts a variadic parameter of Option functions that can be used to configure the logger.
 // The default configuration includes:
 // - isProduction: false
-// - serviceName: "The Lanky Service"
+// - serviceName: "The Lanky Service", attached to every entry as the service field
 // - additionalFields: an empty map[string]any
 //
 // Example usage:
@@ -84,25 +208,94 @@ func NewInstance(opts ...Option) *logrus.Logger {
 		level = logrus.DebugLevel
 	}
 
+	colored := isTerminal()
+	if conf.forceColor != nil {
+		colored = *conf.forceColor
+	}
+	if conf.jsonFormat {
+		colored = false
+	}
+
 	log := logrus.New()
 	log.SetLevel(level)
-	log.SetOutput(colorable.NewColorableStdout())
-	log.AddHook(&defaultHookConfig{fields: conf.additionalFields})
+	if colored {
+		log.SetOutput(colorable.NewColorableStdout())
+	} else {
+		log.SetOutput(os.Stdout)
+	}
+	if conf.jsonFormat {
+		log.SetFormatter(newJSONFormatter(conf.timeFormat))
+	} else {
+		log.SetFormatter(&logrus.TextFormatter{
+			ForceColors:     colored,
+			DisableColors:   !colored,
+			FullTimestamp:   len(conf.timeFormat) > 0 || conf.utc,
+			TimestampFormat: conf.timeFormat,
+		})
+	}
+	log.AddHook(&defaultHookConfig{service: conf.serviceName, fields: conf.additionalFields})
+
+	if conf.errorReporter != nil {
+		log.AddHook(&errorReporterHook{report: conf.errorReporter})
+	}
+
+	fileFormatter := newJSONFormatter(conf.timeFormat)
+
+	if conf.utc {
+		log.SetFormatter(&utcFormatter{Formatter: log.Formatter})
+		fileFormatter = &utcFormatter{Formatter: fileFormatter}
+	}
+
+	if conf.sampling > 1 {
+		log.SetFormatter(&samplingFormatter{Formatter: log.Formatter, every: uint64(conf.sampling)})
+		fileFormatter = &samplingFormatter{Formatter: fileFormatter, every: uint64(conf.sampling)}
+	}
+
+	if conf.fileOutput != nil {
+		log.AddHook(newFileHook(conf.fileOutput, fileFormatter))
+	}
 
 	return log
 }
 
 type defaultHookConfig struct {
-	fields map[string]any
+	service string
+	fields  map[string]any
 }
 
 func (dhc *defaultHookConfig) Fire(entry *logrus.Entry) error {
+	if _, ok := entry.Data[ServiceField]; !ok && len(dhc.service) > 0 {
+		entry.Data[ServiceField] = dhc.service
+	}
 	for k, v := range dhc.fields {
 		entry.Data[k] = v
 	}
+	for k, v := range FieldsFromContext(entry.Context) {
+		// The fields set on the entry itself are more specific than the context ones.
+		if _, ok := entry.Data[k]; !ok {
+			entry.Data[k] = v
+		}
+	}
+	if id := RequestIDFromContext(entry.Context); len(id) > 0 {
+		entry.Data[RequestIDField] = id
+	}
 	return nil
 }
 
 func (dhc *defaultHookConfig) Levels() []logrus.Level {
 	return logrus.AllLevels
 }
+
+// ServiceName returns the service name of a logger built by NewInstance, see SetServiceName,
+// or an empty string for any other logger.
+func ServiceName(log *logrus.Logger) string {
+	if log == nil {
+		return ""
+	}
+	for _, hook := range log.Hooks[logrus.InfoLevel] {
+		if dhc, ok := hook.(*defaultHookConfig); ok {
+			return dhc.service
+		}
+	}
+	return ""
+}
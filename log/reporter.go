@@ -0,0 +1,25 @@
+package lanky_logger
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// errorReporterHook forwards the entries at Error level and above to an error reporting function,
+// such as a Sentry or Rollbar client.
+type errorReporterHook struct {
+	report func(entry *logrus.Entry)
+}
+
+// Fire invokes the reporter, recovering from any panic so a faulty reporter never breaks the logging path.
+func (erh *errorReporterHook) Fire(entry *logrus.Entry) error {
+	defer func() {
+		_ = recover()
+	}()
+
+	erh.report(entry)
+	return nil
+}
+
+func (erh *errorReporterHook) Levels() []logrus.Level {
+	return []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel}
+}
@@ -0,0 +1,29 @@
+package lanky_logger
+
+import (
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// samplingFormatter wraps a formatter and only formats every Nth entry of the sampled levels.
+// Logrus hooks cannot drop an entry, so sampling is done at the formatter level:
+// a dropped entry is formatted to an empty byte slice and nothing is written.
+// Warn and above are never sampled.
+type samplingFormatter struct {
+	logrus.Formatter
+	every    uint64
+	counters [logrus.TraceLevel + 1]atomic.Uint64
+}
+
+func (sf *samplingFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if entry.Level <= logrus.WarnLevel || int(entry.Level) >= len(sf.counters) {
+		return sf.Formatter.Format(entry)
+	}
+
+	if sf.counters[entry.Level].Add(1)%sf.every != 1 {
+		return nil, nil
+	}
+
+	return sf.Formatter.Format(entry)
+}
@@ -0,0 +1,99 @@
+package lanky_rabbitmq
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// PublishBatch encrypts and publishes all the messages to the topic, then waits for the publisher
+// confirmations of the whole batch at once instead of running a retry loop per message.
+// The returned slice has one entry per message, nil when the broker confirmed it.
+// Each message is attempted once; Retries and DelayRetries of the option are ignored,
+// callers can republish the failed messages. Mandatory messages returned as unroutable fail with ErrUnroutable.
+// Every message fails with ErrExchangeNotFound when the Exchange of the option does not exist.
+func (c *lrmq) PublishBatch(
+	ctx context.Context,
+	topic string,
+	messages [][]byte,
+	option *LankyPublisherOption,
+) []error {
+	errs := make([]error, len(messages))
+	if len(messages) == 0 {
+		return errs
+	}
+
+	ps := newPublishSettings(c.publishOption(option), c.config.ExchangeName)
+
+	// The whole batch is published on the same channel, whose confirmations are awaited.
+	ch, tracker := c.current()
+
+	err := c.checkExchange(ps.exchange)
+	if err == nil {
+		err = tracker.enableConfirms(ch)
+	}
+	if err != nil {
+		c.log.Infof("❌ Failed to prepare the batch on topic %s", topic)
+		c.log.Error(err)
+		for i := range errs {
+			errs[i] = err
+			c.metrics.publish(topic, false)
+			c.stats.record(0, err)
+		}
+		return errs
+	}
+
+	ids := make([]string, len(messages))
+	confirms := make([]*amqp091.DeferredConfirmation, len(messages))
+
+	c.log.Infof("🔼 [%d] Publish batch topic %s", len(messages), topic)
+
+	for i, message := range messages {
+		ids[i] = uuid.New().String()
+
+		body, err := c.crp.EncryptToBytes(message)
+		if err == nil {
+			err = c.checkSize(body)
+		}
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+
+		if ps.mandatory {
+			tracker.track(ids[i])
+		}
+
+		confirms[i], errs[i] = ch.PublishWithDeferredConfirmWithContext(
+			ctx,
+			ps.exchange,
+			c.routingKey(topic),
+			ps.mandatory,
+			false,
+			ps.publishing(ids[i], body),
+		)
+		if errs[i] != nil && ps.mandatory {
+			tracker.untrack(ids[i])
+		}
+	}
+
+	failed := 0
+	for i, confirm := range confirms {
+		if errs[i] == nil {
+			errs[i] = tracker.awaitReturn(ctx, ids[i], confirm)
+		}
+
+		if errs[i] != nil {
+			failed++
+			c.log.Infof("❌ [%s] Failed publish topic %s", ids[i], topic)
+			c.log.Error(errs[i])
+		}
+		c.metrics.publish(topic, errs[i] == nil)
+		c.stats.record(1, errs[i])
+	}
+
+	c.log.Infof("✅ [%d/%d] Success publish batch topic %s", len(messages)-failed, len(messages), topic)
+
+	return errs
+}
@@ -0,0 +1,64 @@
+package lanky_rabbitmq
+
+import (
+	"sync"
+	"time"
+)
+
+// Deduplicator remembers the IDs of the processed messages, so a redelivered message is not consumed twice.
+// Seen reports whether the ID was already processed, without recording it. Mark records the ID once its message
+// was consumed successfully, so a message failing to decrypt or to be consumed is processed again when redelivered
+// or replayed. It must be safe for concurrent use.
+// It can be backed by a shared store such as Redis to deduplicate across instances.
+type Deduplicator interface {
+	Seen(id string) bool
+	Mark(id string)
+}
+
+// memoryDeduplicator is an in-memory Deduplicator forgetting the IDs after a TTL.
+type memoryDeduplicator struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	seen    map[string]time.Time
+	sweptAt time.Time
+}
+
+// NewMemoryDeduplicator creates an in-memory Deduplicator remembering every ID for the given TTL.
+// The expired IDs are swept at most once per TTL, while marking an ID.
+// It only deduplicates the deliveries of the current process.
+//
+// Example usage:
+//
+//	conf.Deduplicator = NewMemoryDeduplicator(time.Hour)
+func NewMemoryDeduplicator(ttl time.Duration) Deduplicator {
+	return &memoryDeduplicator{
+		ttl:     ttl,
+		seen:    make(map[string]time.Time),
+		sweptAt: time.Now(),
+	}
+}
+
+func (d *memoryDeduplicator) Seen(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	expiresAt, ok := d.seen[id]
+	return ok && time.Now().Before(expiresAt)
+}
+
+func (d *memoryDeduplicator) Mark(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(d.sweptAt) >= d.ttl {
+		for seenID, expiresAt := range d.seen {
+			if !now.Before(expiresAt) {
+				delete(d.seen, seenID)
+			}
+		}
+		d.sweptAt = now
+	}
+
+	d.seen[id] = now.Add(d.ttl)
+}
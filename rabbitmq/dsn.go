@@ -0,0 +1,68 @@
+package lanky_rabbitmq
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/rabbitmq/amqp091-go"
+	llt "github.com/the-lanky/go/types"
+)
+
+// defaultPort is the AMQP port used when the configuration does not set one.
+const defaultPort = 5672
+
+// buildDsn returns the DSN to dial: the configured Dsn when set, otherwise a DSN built from the
+// structured Username, Password, Host, Port and VHost fields, with the credentials and vhost escaped.
+// The AuthMechanism, when set, is passed through the auth_mechanism query parameter.
+// When a TLS field is set, the built DSN uses the amqps scheme and the port defaults to 5671.
+func buildDsn(conf llt.LankyRabbitConf) (string, error) {
+	if len(strings.TrimSpace(conf.Dsn)) > 0 {
+		return conf.Dsn, nil
+	}
+
+	scheme, port := "amqp", defaultPort
+	if hasTLS(conf) {
+		scheme, port = "amqps", defaultTLSPort
+	}
+
+	if len(conf.Port) > 0 {
+		p, err := strconv.Atoi(conf.Port)
+		if err != nil {
+			return "", fmt.Errorf("Port should be a number: %w", err)
+		}
+		port = p
+	}
+
+	uri := amqp091.URI{
+		Scheme:   scheme,
+		Host:     conf.Host,
+		Port:     port,
+		Username: conf.Username,
+		Password: conf.Password,
+		Vhost:    conf.VHost,
+	}
+
+	if len(uri.Username) == 0 {
+		uri.Username = "guest"
+	}
+	if len(uri.Password) == 0 {
+		uri.Password = "guest"
+	}
+	if len(uri.Vhost) == 0 {
+		uri.Vhost = "/"
+	}
+
+	dsn := uri.String()
+
+	if len(conf.AuthMechanism) > 0 {
+		sep := "?"
+		if strings.Contains(dsn, "?") {
+			sep = "&"
+		}
+		dsn += sep + "auth_mechanism=" + url.QueryEscape(strings.ToLower(conf.AuthMechanism))
+	}
+
+	return dsn, nil
+}
@@ -0,0 +1,43 @@
+package lanky_rabbitmq
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// ErrExchangeNotFound is returned by Publish and PublishBatch, without retrying, when the Exchange
+// of the publisher option does not exist on the broker.
+var ErrExchangeNotFound = errors.New("lanky rabbitmq: exchange not found")
+
+// checkExchange returns ErrExchangeNotFound when the exchange, overriding the configured one, does not exist.
+// Publishing to a missing exchange makes the broker close the channel, which would stop every publish and
+// the consumers sharing it, so the exchange is declared passively on a dedicated channel first.
+// The exchanges found are cached, the configured exchange is never checked.
+func (c *lrmq) checkExchange(exchange string) error {
+	if exchange == c.config.ExchangeName {
+		return nil
+	}
+	if _, ok := c.exchanges.Load(exchange); ok {
+		return nil
+	}
+
+	ch, err := c.conn().Channel()
+	if err != nil {
+		return fmt.Errorf("failed to open the exchange check channel: %w", err)
+	}
+	defer ch.Close()
+
+	// The kind and the flags are ignored by the broker for a passive declaration.
+	if err := ch.ExchangeDeclarePassive(exchange, "", false, false, false, false, nil); err != nil {
+		var amqpErr *amqp091.Error
+		if errors.As(err, &amqpErr) && amqpErr.Code == amqp091.NotFound {
+			return fmt.Errorf("%w: %s", ErrExchangeNotFound, exchange)
+		}
+		return err
+	}
+
+	c.exchanges.Store(exchange, struct{}{})
+	return nil
+}
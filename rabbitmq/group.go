@@ -0,0 +1,118 @@
+package lanky_rabbitmq
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// consumerGroups tracks the dedicated channels opened by ListenGroup, so Close can release them.
+type consumerGroups struct {
+	mu       sync.Mutex
+	channels map[string]*amqp091.Channel
+}
+
+// open opens a dedicated channel for the group on the connection.
+// It returns an error if the group is already listening.
+func (g *consumerGroups) open(con *amqp091.Connection, group string) (*amqp091.Channel, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.channels[group]; ok {
+		return nil, fmt.Errorf("consumer group %s is already listening", group)
+	}
+
+	ch, err := con.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a channel for consumer group %s: %w", group, err)
+	}
+
+	if g.channels == nil {
+		g.channels = make(map[string]*amqp091.Channel)
+	}
+	g.channels[group] = ch
+
+	return ch, nil
+}
+
+// release removes the group and closes its channel, e.g. when its queue could not be consumed.
+func (g *consumerGroups) release(group string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if ch, ok := g.channels[group]; ok {
+		_ = ch.Close()
+		delete(g.channels, group)
+	}
+}
+
+// reset forgets every group without closing their channels, e.g. once their connection is lost.
+func (g *consumerGroups) reset() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.channels = nil
+}
+
+// drain removes every group and returns their channels.
+func (g *consumerGroups) drain() map[string]*amqp091.Channel {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	channels := g.channels
+	g.channels = nil
+
+	return channels
+}
+
+// ListenGroup starts consuming the topics of the consumers on a dedicated channel opened off the shared connection,
+// so independent groups of consumers process their messages in parallel without opening more connections.
+// Each group consumes its own queue named "<ExchangeQueue>.<group>", declared like the queue of Listen,
+// bound to the topics of its consumers.
+// A panic of a consumer only fails its message, like Listen. If the consumption itself panics,
+// the group rejoins on its channel after the rejoin delay.
+// The channel of the group is closed by Close.
+//
+// Example:
+//
+//	err := rmq.ListenGroup("orders", map[string]LankyConsumer{
+//	    "order.created": {Consumer: OrderCreatedConsumer{}},
+//	})
+func (c *lrmq) ListenGroup(group string, consumers map[string]LankyConsumer) error {
+	if len(consumers) == 0 {
+		c.log.Errorf("❌ [E: %s] [G: %s] %v", c.config.ExchangeName, group, ErrNoConsumers)
+		return ErrNoConsumers
+	}
+
+	var (
+		queue  = derivedQueue(c.config.ExchangeQueue, group)
+		ch     *amqp091.Channel
+		rejoin func()
+	)
+
+	start := func() error {
+		var err error
+		if ch, err = c.groups.open(c.conn(), group); err != nil {
+			return err
+		}
+		if err = c.listen(ch, queue, consumers, rejoin); err != nil {
+			c.groups.release(group)
+			return err
+		}
+		return nil
+	}
+
+	rejoin = func() {
+		if err := c.listen(ch, queue, consumers, rejoin); err != nil {
+			c.log.Errorf("❌ [E: %s] [Q: %s] Consumer group failed to rejoin: %+v", c.config.ExchangeName, queue, err)
+		}
+	}
+
+	if err := start(); err != nil {
+		c.log.Errorf("❌ [E: %s] [Q: %s] %+v", c.config.ExchangeName, queue, err)
+		return err
+	}
+
+	c.restarts.add(start)
+	return nil
+}
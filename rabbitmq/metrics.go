@@ -0,0 +1,125 @@
+package lanky_rabbitmq
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsNamespace = "lanky"
+	metricsSubsystem = "rabbitmq"
+
+	statusSuccess = "success"
+	statusFailure = "failure"
+)
+
+// metrics holds the Prometheus collectors of a LankyRMQ client.
+// A nil *metrics is valid and records nothing, so callers never have to check whether metrics are enabled.
+type metrics struct {
+	published       *prometheus.CounterVec
+	consumed        *prometheus.CounterVec
+	consumeErrors   *prometheus.CounterVec
+	consumeDuration *prometheus.HistogramVec
+}
+
+// newMetrics creates the collectors and registers them against the given registerer.
+// If a collector with the same description is already registered (e.g. by another client
+// in the same process), the existing one is reused instead of failing.
+func newMetrics(reg prometheus.Registerer) (*metrics, error) {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+
+	m := &metrics{
+		published: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "published_total",
+			Help:      "Total number of published messages by topic and status.",
+		}, []string{"topic", "status"}),
+		consumed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "consumed_total",
+			Help:      "Total number of consumed messages by topic.",
+		}, []string{"topic"}),
+		consumeErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "consume_errors_total",
+			Help:      "Total number of messages that failed to be consumed by topic.",
+		}, []string{"topic"}),
+		consumeDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "consume_duration_seconds",
+			Help:      "Duration of the consumer handler by topic.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"topic"}),
+	}
+
+	var err error
+	if m.published, err = register(reg, m.published); err != nil {
+		return nil, err
+	}
+	if m.consumed, err = register(reg, m.consumed); err != nil {
+		return nil, err
+	}
+	if m.consumeErrors, err = register(reg, m.consumeErrors); err != nil {
+		return nil, err
+	}
+	if m.consumeDuration, err = register(reg, m.consumeDuration); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// register registers the collector and returns the already registered one when it exists.
+func register[T prometheus.Collector](reg prometheus.Registerer, c T) (T, error) {
+	if err := reg.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(T); ok {
+				return existing, nil
+			}
+		}
+		return c, err
+	}
+	return c, nil
+}
+
+func (m *metrics) publish(topic string, ok bool) {
+	if m == nil {
+		return
+	}
+
+	status := statusSuccess
+	if !ok {
+		status = statusFailure
+	}
+	m.published.WithLabelValues(topic, status).Inc()
+}
+
+func (m *metrics) consume(topic string) {
+	if m == nil {
+		return
+	}
+	m.consumed.WithLabelValues(topic).Inc()
+}
+
+func (m *metrics) consumeError(topic string) {
+	if m == nil {
+		return
+	}
+	m.consumeErrors.WithLabelValues(topic).Inc()
+}
+
+func (m *metrics) observeConsume(topic string, start time.Time) {
+	if m == nil {
+		return
+	}
+	m.consumeDuration.WithLabelValues(topic).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,45 @@
+package lanky_rabbitmq
+
+import (
+	"strings"
+
+	llt "github.com/the-lanky/go/types"
+)
+
+// namespaceSeparator separates the namespace from the name it prefixes, e.g. "tenant-a.orders".
+const namespaceSeparator = "."
+
+// withNamespace prefixes the configured exchange name and queue with the namespace, if any,
+// so every exchange and queue derived from them, e.g. the queues of ListenGroup and Subscribe, is namespaced.
+// An empty queue is left empty for the broker to name.
+func withNamespace(conf llt.LankyRabbitConf) llt.LankyRabbitConf {
+	if len(conf.Namespace) == 0 {
+		return conf
+	}
+	conf.ExchangeName = namespaced(conf.Namespace, conf.ExchangeName)
+	if len(conf.ExchangeQueue) > 0 {
+		conf.ExchangeQueue = namespaced(conf.Namespace, conf.ExchangeQueue)
+	}
+	return conf
+}
+
+// namespaced prefixes the name with the namespace, or returns it as is when the namespace is empty.
+func namespaced(namespace, name string) string {
+	if len(namespace) == 0 {
+		return name
+	}
+	return namespace + namespaceSeparator + name
+}
+
+// routingKey returns the routing key of the logical topic, prefixed with the configured namespace.
+func (c *lrmq) routingKey(topic string) string {
+	return namespaced(c.config.Namespace, topic)
+}
+
+// topicOf returns the logical topic of the routing key of a delivery, without the configured namespace.
+func (c *lrmq) topicOf(routingKey string) string {
+	if len(c.config.Namespace) == 0 {
+		return routingKey
+	}
+	return strings.TrimPrefix(routingKey, c.config.Namespace+namespaceSeparator)
+}
@@ -0,0 +1,36 @@
+package lanky_rabbitmq
+
+import "fmt"
+
+// PurgeQueue removes every message ready in the queue, e.g. to clean up after a test or an incident,
+// and returns the number of messages removed. The messages delivered but not acknowledged yet are kept.
+// The queue is given without the configured namespace, and defaults to the configured ExchangeQueue when empty.
+// It runs on a dedicated channel, so purging a missing queue does not close the shared one.
+//
+// Example usage:
+//
+//	purged, err := rmq.PurgeQueue("orders.dlq")
+func (c *lrmq) PurgeQueue(queue string) (int, error) {
+	if len(queue) == 0 {
+		queue = c.config.ExchangeQueue
+	} else {
+		queue = namespaced(c.config.Namespace, queue)
+	}
+
+	ch, err := c.conn().Channel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to open the purge channel: %w", err)
+	}
+	defer ch.Close()
+
+	c.log.Warnf("⚠️ ⚠️ ⚠️ [Q: %s] Purging queue, its ready messages are DELETED ⚠️ ⚠️ ⚠️", queue)
+
+	purged, err := ch.QueuePurge(queue, false)
+	if err != nil {
+		c.log.Errorf("❌ [Q: %s] Failed to purge queue: %+v", queue, err)
+		return 0, err
+	}
+
+	c.log.Warnf("🧹 [Q: %s] %d message(s) purged", queue, purged)
+	return purged, nil
+}
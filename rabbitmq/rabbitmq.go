@@ -2,8 +2,14 @@ package lanky_rabbitmq
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -40,26 +46,185 @@ type Consumer interface {
 // LankyConsumer represents a consumer for RabbitMQ.
 type LankyConsumer struct {
 	Consumer Consumer
+
+	// OnError is invoked, after the failure is logged, when the message of the topic cannot be decrypted
+	// or when Consume returns an error, e.g. to alert, record a metric or park the message.
+	// On a decryption failure the delivery still holds the encrypted body. It is optional.
+	OnError func(topic string, msg amqp091.Delivery, err error)
+
+	// Raw passes the body to the consumer unchanged, without decrypting it, for the topics carrying
+	// plain text messages, e.g. produced by a third party or published with PublishRaw.
+	Raw bool
 }
 
 // LankyPublisherOption represents the options for configuring a LankyPublisher.
 type LankyPublisherOption struct {
 	Retries      Retries       // The number of retries for publishing a message.
 	DelayRetries time.Duration // The delay between retries for publishing a message.
+	ContentType  string        // The content type of the message. Defaults to "text/plain".
+	Expiration   time.Duration // The TTL of the message. The broker discards it once expired. Zero means no expiration.
+	Priority     uint8         // The priority of the message. Only honored when the queue is declared with x-max-priority, see LankyRabbitConf.QueueMaxPriority.
+	Mandatory    bool          // Whether the broker must return the message when it cannot be routed to any queue. A returned message counts as a failed attempt.
+	Headers      amqp091.Table // The headers of the message, e.g. for headers exchange routing or metadata like schema version or tenant id.
+	Exchange     string        // The exchange to publish to instead of the configured one, not namespaced. Declaring it is the caller's responsibility, see Channel. A missing exchange fails with ErrExchangeNotFound, checked once on a dedicated channel since publishing to it would close the shared one.
+
+	ReplyTo       string // The queue the consumer should reply to, available as ReplyTo on the delivery. See RPC and Reply.
+	CorrelationId string // The ID correlating a reply with its request, available as CorrelationId on the delivery.
+}
+
+// defaultContentType is the content type used when the publisher option does not set one.
+const defaultContentType = "text/plain"
+
+// rawContentType is the content type used by PublishRaw when the publisher option does not set one.
+const rawContentType = "application/json"
+
+// publishSettings holds the effective publish settings, resolved from an optional LankyPublisherOption.
+type publishSettings struct {
+	retries     Retries
+	delay       time.Duration
+	contentType string
+	expiration  string
+	priority    uint8
+	mandatory   bool
+	headers     amqp091.Table
+	exchange    string
+	replyTo     string
+	correlation string
+}
+
+// newPublishSettings resolves the publish settings from the option, falling back to the defaults
+// (a single attempt, one second delay, text/plain content type, the configured exchange) for the fields that are not set.
+func newPublishSettings(option *LankyPublisherOption, exchange string) publishSettings {
+	ps := publishSettings{
+		retries:     NewRetries(1),
+		delay:       time.Second * 1,
+		contentType: defaultContentType,
+		exchange:    exchange,
+	}
+
+	if option != nil {
+		if rtr := option.Retries; rtr > 0 {
+			ps.retries = rtr
+		}
+		if dl := option.DelayRetries; dl > 0 {
+			ps.delay = dl
+		}
+		if ct := option.ContentType; len(ct) > 0 {
+			ps.contentType = ct
+		}
+		if exp := option.Expiration; exp > 0 {
+			ps.expiration = strconv.FormatInt(exp.Milliseconds(), 10)
+		}
+		if ex := option.Exchange; len(ex) > 0 {
+			ps.exchange = ex
+		}
+		ps.replyTo = option.ReplyTo
+		ps.correlation = option.CorrelationId
+		ps.priority = option.Priority
+		ps.mandatory = option.Mandatory
+		if len(option.Headers) > 0 {
+			ps.headers = make(amqp091.Table, len(option.Headers))
+			for k, v := range option.Headers {
+				ps.headers[k] = v
+			}
+		}
+	}
+
+	return ps
+}
+
+// publishing builds the AMQP publishing of an already encrypted body.
+func (ps publishSettings) publishing(id string, body []byte) amqp091.Publishing {
+	return amqp091.Publishing{
+		Headers:       ps.headers,
+		ContentType:   ps.contentType,
+		MessageId:     id,
+		ReplyTo:       ps.replyTo,
+		CorrelationId: ps.correlation,
+		Expiration:    ps.expiration,
+		Priority:      ps.priority,
+		Body:          body,
+	}
 }
 
 // LankyRMQ is an interface that represents a RabbitMQ client for publishing and consuming messages.
 type LankyRMQ interface {
 	// Publish publishes a message to the specified topic.
 	// It takes a context, topic string, message byte slice, and an optional LankyPublisherOption.
-	Publish(ctx context.Context, topic string, message []byte, option *LankyPublisherOption)
+	// It returns the error of the last attempt when every attempt failed.
+	Publish(ctx context.Context, topic string, message []byte, option *LankyPublisherOption) error
+
+	// PublishWithID publishes a message like Publish and returns the message ID it was published with,
+	// so request/reply callers can correlate the reply.
+	PublishWithID(ctx context.Context, topic string, message []byte, option *LankyPublisherOption) (messageID string, err error)
+
+	// PublishRaw publishes a message like Publish without encrypting it, e.g. for third-party consumers.
+	// The body is readable by anyone with access to the broker.
+	PublishRaw(ctx context.Context, topic string, message []byte, option *LankyPublisherOption) error
+
+	// PublishBatch publishes all the messages to the specified topic, waiting for the broker confirmations of the batch at once.
+	// It returns one error per message, nil for the messages that were confirmed.
+	PublishBatch(ctx context.Context, topic string, messages [][]byte, option *LankyPublisherOption) []error
 
 	// Listen starts listening for messages on the specified consumers.
 	// It takes a map of consumer names to LankyConsumer instances.
 	Listen(consumers map[string]LankyConsumer)
 
+	// ListenGroup starts listening for messages on the specified consumers on a dedicated channel
+	// sharing the connection, consuming the queue "<ExchangeQueue>.<group>".
+	// It returns an error if there are no consumers, the group is already listening or its queue cannot be consumed.
+	ListenGroup(group string, consumers map[string]LankyConsumer) error
+
+	// ListenSubscriptions starts consuming every subscription on its own queue and channel,
+	// with the binding pattern, ack mode and concurrency of the subscription.
+	ListenSubscriptions(subscriptions []Subscription) error
+
+	// Subscribe binds a dedicated queue to the topic and returns a channel of decrypted deliveries.
+	// The channel is closed once the context is cancelled.
+	Subscribe(ctx context.Context, topic string) (<-chan amqp091.Delivery, error)
+
+	// Replay moves up to max messages from the dead-letter queue to the target topic, re-encrypting them,
+	// and returns the number of messages replayed.
+	Replay(ctx context.Context, dlq string, target string, max int) (int, error)
+
+	// PurgeQueue removes every message ready in the queue, the configured ExchangeQueue when empty,
+	// and returns the number of messages removed. It is destructive.
+	PurgeQueue(queue string) (int, error)
+
+	// RPC publishes the message to the topic with a temporary reply queue and waits for the correlated reply,
+	// returning its decrypted body. It returns the context error when no reply arrives before the context is done.
+	RPC(ctx context.Context, topic string, message []byte) ([]byte, error)
+
+	// Reply encrypts the message and publishes it to the reply queue of the request, with its correlation ID,
+	// e.g. from the Consume method of the consumer of an RPC request.
+	Reply(ctx context.Context, request amqp091.Delivery, message []byte) error
+
+	// SetDefaultPublishOption sets the option used by Publish, PublishWithID and PublishBatch when they are given a nil one,
+	// instead of a single attempt with the default settings. A per-call option still overrides it entirely.
+	// The types package cannot refer to LankyPublisherOption, so it is set on the client rather than in LankyRabbitConf.
+	SetDefaultPublishOption(option *LankyPublisherOption)
+
+	// Stats returns the counts of successful and failed publishes, the retries consumed
+	// and the last publish error with its time, e.g. for a health endpoint.
+	Stats() PublishStats
+
+	// DeclareExchange declares the configured exchange, so a publisher-only service can ensure
+	// the topology exists before its first Publish. It is idempotent.
+	DeclareExchange() error
+
 	// Close closes the connection to the RabbitMQ server.
-	Close()
+	// It returns the failures of the resources that could not be closed, without stopping at the first one.
+	// It is idempotent: once the connection is closed, further calls are a no-op.
+	Close() error
+
+	// Channel returns the underlying AMQP channel, e.g. to declare custom exchanges.
+	// It is an escape hatch: the channel is shared with the client, so mutating its state
+	// (closing it, switching modes, changing QoS) is at the caller's own risk.
+	Channel() *amqp091.Channel
+
+	// Connection returns the underlying AMQP connection, e.g. to inspect its state.
+	// Like Channel, closing or otherwise mutating it is at the caller's own risk.
+	Connection() *amqp091.Connection
 }
 
 type lrmq struct {
@@ -68,53 +233,140 @@ type lrmq struct {
 	config     llt.LankyRabbitConf
 	log        *logrus.Logger
 	crp        lcp.LankyCrypto
+	metrics    *metrics
+	stats      publishStats
+	defaultOpt atomic.Pointer[LankyPublisherOption]
+	returns    *returnTracker
+	groups     consumerGroups
+	tags       consumerTags
+	closed     atomic.Bool
+
+	dsn      string         // The DSN dialed again on reconnection.
+	dialConf amqp091.Config // The TLS, heartbeat and dial settings of the connection.
+	connMu   sync.RWMutex   // Guards the connection, channel and returns, replaced on reconnection.
+	restarts restarts       // The consumers started again on reconnection.
+
+	exchanges sync.Map // The exchanges of the publisher options found on the broker, see checkExchange.
 }
 
 // Publish publishes a message to a RabbitMQ topic.
 //
 // Parameters:
 //   - ctx: The context.Context for the operation.
-//   - topic: The topic to publish the message to.
+//   - topic: The topic to publish the message to, prefixed with the configured namespace as routing key.
 //   - message: The message to be published.
 //   - option: The optional LankyPublisherOption for configuring retries and delays.
 //
 // Description:
 //
-//	This function publishes a message to a RabbitMQ topic. It takes a context.Context, a topic string, a message byte slice, and an optional LankyPublisherOption as parameters. The LankyPublisherOption can be used to configure the number of retries and the delay between retries. If the LankyPublisherOption is not provided, default values will be used.
+//	This function publishes a message to a RabbitMQ topic. It takes a context.Context, a topic string, a message byte slice, and an optional LankyPublisherOption as parameters. The LankyPublisherOption can be used to configure the number of retries, the delay between retries and the content type of the message. If the LankyPublisherOption is not provided, default values will be used.
 //
 //	The function uses a loop to attempt publishing the message multiple times until it succeeds or reaches the maximum number of retries. Each attempt is logged with the try number and a unique identifier. If message encryption fails, the function logs an error and waits for the specified delay before retrying. If publishing to the RabbitMQ channel fails, the function logs an error and waits for the specified delay before retrying. If the message is successfully published, the function logs a success message.
 //
+//	The context bounds the whole retry loop: it is checked before every attempt and interrupts the delay between attempts, so the function returns the context error as soon as the context is done.
+//
+//	When the Mandatory option is set, the channel is switched to confirm mode and each attempt waits for the broker confirmation. A message returned by the broker as unroutable is treated as a failed attempt, and ErrUnroutable is returned once the retries are exhausted.
+//
+//	An encrypted body larger than MaxMessageBytes is rejected with ErrMessageTooLarge, without retrying.
+//
+//	An Exchange of the option missing on the broker is rejected with ErrExchangeNotFound, without retrying.
+//
 //	Note: This function assumes that the RabbitMQ channel and configuration have been properly set up before calling this function.
 func (c *lrmq) Publish(
 	ctx context.Context,
 	topic string,
 	message []byte,
 	option *LankyPublisherOption,
-) {
+) error {
+	return c.publish(ctx, uuid.New().String(), topic, message, option, true)
+}
+
+// PublishRaw publishes a message like Publish, with the same retries and options, but without encrypting it,
+// e.g. for third-party consumers that cannot decrypt the payloads. The content type defaults to application/json
+// instead of text/plain when the option, or the default one, does not set it.
+//
+// Security: the body travels and sits in the queues in plain text, readable by anyone with access to the broker,
+// and the consumers of this package cannot decrypt it unless encryption is disabled. Only publish data that is
+// meant to leave the service, and keep Publish for the internal topics.
+func (c *lrmq) PublishRaw(
+	ctx context.Context,
+	topic string,
+	message []byte,
+	option *LankyPublisherOption,
+) error {
+	raw := LankyPublisherOption{}
+	if opt := c.publishOption(option); opt != nil {
+		raw = *opt
+	}
+	if len(raw.ContentType) == 0 {
+		raw.ContentType = rawContentType
+	}
+
+	return c.publish(ctx, uuid.New().String(), topic, message, &raw, false)
+}
+
+// PublishWithID publishes a message like Publish and returns the generated message ID, used as MessageId
+// for every attempt, e.g. to register the correlation of a reply before it arrives.
+// The ID is returned even when the publish failed.
+func (c *lrmq) PublishWithID(
+	ctx context.Context,
+	topic string,
+	message []byte,
+	option *LankyPublisherOption,
+) (string, error) {
+	uid := uuid.New().String()
+	return uid, c.publish(ctx, uid, topic, message, option, true)
+}
+
+func (c *lrmq) SetDefaultPublishOption(option *LankyPublisherOption) {
+	if option != nil {
+		copied := *option
+		option = &copied
+	}
+	c.defaultOpt.Store(option)
+}
+
+// publishOption returns the given option, or the default one set by SetDefaultPublishOption when it is nil.
+func (c *lrmq) publishOption(option *LankyPublisherOption) *LankyPublisherOption {
+	if option != nil {
+		return option
+	}
+	return c.defaultOpt.Load()
+}
+
+// publish publishes the message under the given message ID, see Publish.
+// The message is encrypted unless encrypt is false, see PublishRaw.
+func (c *lrmq) publish(
+	ctx context.Context,
+	uid string,
+	topic string,
+	message []byte,
+	option *LankyPublisherOption,
+	encrypt bool,
+) error {
 	var (
-		retries = NewRetries(1)
-		delay   = time.Second * 1
+		ps        = newPublishSettings(c.publishOption(option), c.config.ExchangeName)
+		retries   = ps.retries
+		delay     = ps.delay
+		mandatory = ps.mandatory
 
 		try = NewRetries(1)
-		uid = uuid.New().String()
 
 		mu      sync.Mutex
 		success bool
+		lastErr error
 	)
 
-	if option != nil {
-		if rtr := option.Retries; rtr > 0 {
-			retries = rtr
-		}
-		if dl := option.DelayRetries; dl > 0 {
-			delay = dl
-		}
-	}
-
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	for ok := true; ok; ok = try <= retries && !success {
+		if err := ctx.Err(); err != nil {
+			c.log.Infof("❌ [%d] [%s] Abort publish topic %s: %+v", try, uid, topic, err)
+			lastErr = err
+			break
+		}
+
 		mu.Lock()
 
 		c.log.Infof("🔼 [%d] [%s] Publish topic %s", try, uid, topic)
@@ -123,32 +375,81 @@ func (c *lrmq) Publish(
 			c.log.Debugf("🚀 Body: %s", string(message))
 		}
 
-		body, err := c.crp.EncryptToBytes(message)
+		body, err := message, error(nil)
+		if encrypt {
+			body, err = c.crp.EncryptToBytes(message)
+		}
 		if err != nil {
 			c.log.Infof("❌ [%d] [%s] Failed publish topic %s. Error message encryption!", try, uid, topic)
 			c.log.Error(err)
+			lastErr = err
 			try++
-			time.Sleep(delay)
+			sleepContext(ctx, delay)
 			mu.Unlock()
 			continue
 		}
 
-		if err := c.channel.PublishWithContext(
+		// A larger body would fail on every attempt, so the retries are skipped.
+		if err := c.checkSize(body); err != nil {
+			c.log.Errorf("❌ [%d] [%s] Failed publish topic %s: %+v", try, uid, topic, err)
+			lastErr = err
+			try++
+			mu.Unlock()
+			break
+		}
+
+		// A missing exchange would fail on every attempt, so the retries are skipped.
+		if err := c.checkExchange(ps.exchange); err != nil {
+			c.log.Errorf("❌ [%d] [%s] Failed publish topic %s: %+v", try, uid, topic, err)
+			lastErr = err
+			try++
+			if errors.Is(err, ErrExchangeNotFound) {
+				mu.Unlock()
+				break
+			}
+			sleepContext(ctx, delay)
+			mu.Unlock()
+			continue
+		}
+
+		// The channel is taken per attempt, a reconnect between two attempts replaces it.
+		ch, tracker := c.current()
+
+		if mandatory {
+			if err := tracker.enableConfirms(ch); err != nil {
+				c.log.Infof("❌ [%d] [%s] Failed to enable publisher confirms for topic %s", try, uid, topic)
+				c.log.Error(err)
+				lastErr = err
+				try++
+				sleepContext(ctx, delay)
+				mu.Unlock()
+				continue
+			}
+			tracker.track(uid)
+		}
+
+		confirm, err := ch.PublishWithDeferredConfirmWithContext(
 			ctx,
-			c.config.ExchangeName,
-			topic,
-			false,
+			ps.exchange,
+			c.routingKey(topic),
+			mandatory,
 			false,
-			amqp091.Publishing{
-				ContentType: "text/plain",
-				MessageId:   uid,
-				Body:        body,
-			},
-		); err != nil {
+			ps.publishing(uid, body),
+		)
+		if mandatory {
+			if err == nil {
+				err = tracker.awaitReturn(ctx, uid, confirm)
+			} else {
+				tracker.untrack(uid)
+			}
+		}
+
+		if err != nil {
+			lastErr = err
 			c.log.Infof("❌ [%d] [%s] Failed publish topic %s", try, uid, topic)
 			c.log.Error(err)
 			try++
-			time.Sleep(delay)
+			sleepContext(ctx, delay)
 		} else {
 			success = true
 			c.log.Infof("✅ [%d] [%s] Success publish topic %s", try, uid, topic)
@@ -156,14 +457,41 @@ func (c *lrmq) Publish(
 
 		mu.Unlock()
 	}
+
+	c.metrics.publish(topic, success)
+
+	if success {
+		c.stats.record(try, nil)
+		return nil
+	}
+
+	c.stats.record(try-1, lastErr)
+	return lastErr
+}
+
+// sleepContext pauses for the given delay, or until the context is done.
+func sleepContext(ctx context.Context, delay time.Duration) {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
 }
 
 // Listen starts consuming messages from RabbitMQ for the specified consumers.
 // It declares the exchange and queue, binds the queue to the specified topics,
 // and starts consuming messages from the queue. It invokes the Consume method
-// of the consumer for each consumed message. If a panic occurs during message
-// consumption, it logs the error, waits for the specified rejoin delay, and
-// then restarts the consumer.
+// of the consumer for each consumed message. A panic of a consumer only fails
+// its message, which is logged and passed to OnError, and the consumption goes on.
+// If a panic occurs outside of the consumers, it logs the error, waits for the
+// specified rejoin delay, and then restarts the consumer.
+// The topics are bound with the configured namespace, which is stripped from the routing key of the deliveries.
+// The queue is declared durable by default, see QueueDurable, QueueAutoDelete and QueueExclusive.
+// For a broadcast, where every instance receives every message of a fanout exchange, each instance
+// consumes its own temporary queue: set QueueExclusive and QueueAutoDelete, and leave ExchangeQueue empty
+// so the broker generates a unique name. The queue is deleted once the instance disconnects.
 //
 // Parameters:
 //   - consumers: A map of topics and corresponding LankyConsumer instances.
@@ -182,46 +510,69 @@ func (c *lrmq) Publish(
 //	The LankyConsumer interface should have a Consume method that accepts a
 //	*amqp.Delivery parameter and returns an error.
 func (c *lrmq) Listen(consumers map[string]LankyConsumer) {
-	var mu sync.Mutex
+	if len(consumers) == 0 {
+		c.log.Warnf("⚠️ [E: %s] [Q: %s] %v, nothing is consumed", c.config.ExchangeName, c.config.ExchangeQueue, ErrNoConsumers)
+		return
+	}
 
-	if err := c.channel.ExchangeDeclare(
-		c.config.ExchangeName,
-		c.config.ExchangeType,
-		true,
-		false,
-		false,
-		false,
-		nil,
-	); err != nil {
-		c.log.Fatalf(
-			"❌ [E: %s] [Q: %s] Consumer failed to declare an exchange: %+v",
-			c.config.ExchangeName,
-			c.config.ExchangeQueue,
-			err,
-		)
+	var rejoin func()
+
+	start := func() error {
+		return c.listen(c.ch(), c.config.ExchangeQueue, consumers, rejoin)
 	}
 
-	q, err := c.channel.QueueDeclare(
-		c.config.ExchangeQueue,
-		true,
-		false,
-		false,
+	rejoin = func() {
+		if err := start(); err != nil {
+			c.log.Fatalf(
+				"❌ [E: %s] [Q: %s] %+v",
+				c.config.ExchangeName,
+				c.config.ExchangeQueue,
+				err,
+			)
+		}
+	}
+
+	rejoin()
+	c.restarts.add(start)
+}
+
+// ErrNoConsumers is returned by ListenGroup when the consumer map is empty, since nothing would be consumed.
+// Listen logs it as a warning and returns without consuming.
+var ErrNoConsumers = errors.New("lanky rabbitmq: no consumers to listen to")
+
+// listen declares the exchange and the queue on the channel, binds the queue to the topics of the consumers
+// and starts consuming it in a goroutine. It returns ErrNoConsumers without declaring anything when there are no consumers. The panics of the consumers are isolated to their message.
+// The rejoin function is invoked, after the rejoin delay, when the consumption itself panics.
+func (c *lrmq) listen(
+	ch *amqp091.Channel,
+	queue string,
+	consumers map[string]LankyConsumer,
+	rejoin func(),
+) error {
+	if len(consumers) == 0 {
+		return ErrNoConsumers
+	}
+
+	if err := c.declareExchange(); err != nil {
+		return fmt.Errorf("Consumer failed to declare an exchange: %w", err)
+	}
+
+	q, err := ch.QueueDeclare(
+		queue,
+		c.queueDurable(),
+		c.config.QueueAutoDelete,
+		c.config.QueueExclusive,
 		false,
-		nil,
+		c.queueArgs(),
 	)
 	if err != nil {
-		c.log.Fatalf(
-			"❌ [E: %s] [Q: %s] Consumer failed to declare a queue: %+v",
-			c.config.ExchangeName,
-			c.config.ExchangeQueue,
-			err,
-		)
+		return fmt.Errorf("Consumer failed to declare a queue: %w", err)
 	}
 
 	for topic := range consumers {
-		if err = c.channel.QueueBind(
+		if err = ch.QueueBind(
 			q.Name,
-			topic,
+			c.routingKey(topic),
 			c.config.ExchangeName,
 			false,
 			nil,
@@ -229,7 +580,7 @@ func (c *lrmq) Listen(consumers map[string]LankyConsumer) {
 			c.log.Errorf(
 				"❌ [E: %s] [Q: %s] Consumer failed to listening topic %s",
 				c.config.ExchangeName,
-				c.config.ExchangeQueue,
+				q.Name,
 				topic,
 			)
 			c.log.Error(err)
@@ -237,15 +588,17 @@ func (c *lrmq) Listen(consumers map[string]LankyConsumer) {
 			c.log.Infof(
 				"✨ [E: %s] [Q: %s] Consumer listening to topic: %s",
 				c.config.ExchangeName,
-				c.config.ExchangeQueue,
+				q.Name,
 				topic,
 			)
 		}
 	}
 
-	messages, err := c.channel.Consume(
+	tag := uuid.New().String()
+
+	messages, err := ch.Consume(
 		q.Name,
-		"",
+		tag,
 		true,
 		false,
 		false,
@@ -253,15 +606,14 @@ func (c *lrmq) Listen(consumers map[string]LankyConsumer) {
 		nil,
 	)
 	if err != nil {
-		c.log.Fatalf(
-			"❌ [E: %s] [Q: %s] Consumer failed to consume message: %+v",
-			c.config.ExchangeName,
-			c.config.ExchangeQueue,
-			err,
-		)
+		return fmt.Errorf("Consumer failed to consume message: %w", err)
 	}
 
+	c.tags.add(ch, tag, 1)
+
 	consumerFn := func() {
+		defer c.tags.done()
+
 		var (
 			topic     string
 			messageId string
@@ -279,21 +631,24 @@ func (c *lrmq) Listen(consumers map[string]LankyConsumer) {
 					*id,
 					*topic,
 				)
+				// The deliveries are no longer read, stop them before rejoining with a new tag.
+				if err := c.tags.cancel(tag); err != nil {
+					c.log.Errorf("❌ [%s] Failed to cancel the consumer: %+v", tag, err)
+				}
 				c.log.Info("🛠️ Rejoin rabbitmq service...")
 				time.Sleep(delay)
-				c.Listen(consumers)
+				rejoin()
 			}
 		}(&topic, &messageId)
 
 		for msg := range messages {
-			mu.Lock()
-			topic = msg.RoutingKey
+			topic = c.topicOf(msg.RoutingKey)
 			messageId = msg.MessageId
 
-			c.log.Infof(
+			c.logMessagef(
 				"🔽 [E: %s] [Q: %s] [%s] Consume topic %s",
 				c.config.ExchangeName,
-				c.config.ExchangeQueue,
+				q.Name,
 				messageId,
 				topic,
 			)
@@ -303,70 +658,229 @@ func (c *lrmq) Listen(consumers map[string]LankyConsumer) {
 				continue
 			}
 
-			decrypted, err := c.crp.DecryptFromBytes(msg.Body)
-			if err != nil {
-				c.log.Errorf(`❌ [%s] Failed to decrypt message`, topic)
-				continue
-			}
+			c.handleSafely(topic, msg, consumers[topic])
+		}
+	}
 
-			if c.config.EnableDebugMessage {
-				c.log.Debug(string(decrypted))
-			}
+	go consumerFn()
 
-			msg.Body = decrypted
+	c.log.Infof(
+		"✅ [E: %s] [Q: %s] Rabbit consumer started...",
+		c.config.ExchangeName,
+		q.Name,
+	)
 
-			err = consumers[topic].Consumer.Consume(msg)
-			if err != nil {
-				c.log.Infof("❌ [%s] Failed...", topic)
-				c.log.Error(err)
-				continue
+	return nil
+}
+
+// handleSafely handles the delivery like handle, recovering from a panic of the consumer so only this delivery fails.
+// The panic is logged, recorded as a consume error and passed to OnError.
+func (c *lrmq) handleSafely(topic string, msg amqp091.Delivery, consumer LankyConsumer) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.log.Errorf("❌ [%s] [%s] Got panic!!! %v", msg.MessageId, topic, r)
+			err = fmt.Errorf("consumer panicked: %v", r)
+			c.metrics.consumeError(topic)
+			if onError := consumer.OnError; onError != nil {
+				onError(topic, msg, err)
 			}
+		}
+	}()
+
+	return c.handle(topic, msg, consumer)
+}
 
-			c.log.Infof("✅ [%s] [%s] Success...", messageId, topic)
+// handle decrypts the delivery, unless the consumer is Raw, and passes it to the consumer, recording the metrics and invoking OnError on failure.
+// It returns the decryption or consumption error. A message already seen by the Deduplicator is skipped,
+// and the ID of a message consumed successfully is marked on it.
+func (c *lrmq) handle(topic string, msg amqp091.Delivery, consumer LankyConsumer) error {
+	if dedup := c.config.Deduplicator; dedup != nil && len(msg.MessageId) > 0 && dedup.Seen(msg.MessageId) {
+		c.logMessagef("⏭️ [%s] [%s] Skip already consumed message", msg.MessageId, topic)
+		return nil
+	}
 
-			mu.Unlock()
+	c.metrics.consume(topic)
+
+	decrypted, err := msg.Body, error(nil)
+	if !consumer.Raw {
+		decrypted, err = c.crp.DecryptFromBytes(msg.Body)
+	}
+	if err != nil {
+		c.log.Errorf(`❌ [%s] Failed to decrypt message: %v`, topic, err)
+		c.metrics.consumeError(topic)
+		if onError := consumer.OnError; onError != nil {
+			onError(topic, msg, err)
 		}
+		return err
 	}
 
-	go consumerFn()
+	if c.config.EnableDebugMessage {
+		c.log.Debug(string(decrypted))
+	}
 
-	c.log.Infof(
-		"✅ [E: %s] [Q: %s] Rabbit consumer started...",
+	msg.Body = decrypted
+
+	start := time.Now()
+	err = consumer.Consumer.Consume(msg)
+	c.metrics.observeConsume(topic, start)
+	c.checkSlowConsume(topic, msg.MessageId, time.Since(start))
+	if err != nil {
+		c.log.Errorf("❌ [%s] [%s] Failed...", msg.MessageId, topic)
+		c.log.Error(err)
+		c.metrics.consumeError(topic)
+		if onError := consumer.OnError; onError != nil {
+			onError(topic, msg, err)
+		}
+		return err
+	}
+
+	if dedup := c.config.Deduplicator; dedup != nil && len(msg.MessageId) > 0 {
+		dedup.Mark(msg.MessageId)
+	}
+
+	c.logMessagef("✅ [%s] [%s] Success...", msg.MessageId, topic)
+	return nil
+}
+
+// checkSlowConsume logs a warning when the consumption of the message took longer than the configured SlowConsumeThreshold.
+func (c *lrmq) checkSlowConsume(topic, messageId string, elapsed time.Duration) {
+	if threshold := c.config.SlowConsumeThreshold; threshold > 0 && elapsed > threshold {
+		c.log.Warnf("🐢 [%s] [%s] Slow consume: took %s, threshold %s", messageId, topic, elapsed, threshold)
+	}
+}
+
+// logMessagef logs a line about a single consumed message, at Info level when LogEveryMessage is set,
+// at Debug level otherwise, so a busy consumer does not flood the logs.
+func (c *lrmq) logMessagef(format string, args ...any) {
+	if c.config.LogEveryMessage {
+		c.log.Infof(format, args...)
+		return
+	}
+	c.log.Debugf(format, args...)
+}
+
+func (c *lrmq) DeclareExchange() error {
+	if err := c.declareExchange(); err != nil {
+		c.log.Errorf("❌ [E: %s] Failed to declare an exchange: %+v", c.config.ExchangeName, err)
+		return err
+	}
+
+	c.log.Infof("✅ [E: %s] Exchange declared", c.config.ExchangeName)
+	return nil
+}
+
+// declareExchange declares the configured exchange as durable. Declaring an existing exchange with the same settings is a no-op.
+func (c *lrmq) declareExchange() error {
+	return c.ch().ExchangeDeclare(
 		c.config.ExchangeName,
-		q.Name,
+		c.config.ExchangeType,
+		true,
+		false,
+		false,
+		false,
+		c.config.ExchangeArgs,
 	)
 }
 
+// queueArgs returns the arguments of the queue declaration: the configured QueueArgs,
+// plus x-max-priority when QueueMaxPriority is set.
+func (c *lrmq) queueArgs() amqp091.Table {
+	if c.config.QueueMaxPriority == 0 {
+		return c.config.QueueArgs
+	}
+
+	args := make(amqp091.Table, len(c.config.QueueArgs)+1)
+	for k, v := range c.config.QueueArgs {
+		args[k] = v
+	}
+	args["x-max-priority"] = int32(c.config.QueueMaxPriority)
+
+	return args
+}
+
+// derivedQueue returns the name of a queue derived from the configured one, e.g. "<ExchangeQueue>.<group>",
+// or an empty name for the broker to generate when the configured queue is empty, see LankyRabbitConf.QueueExclusive.
+func derivedQueue(queue, name string) string {
+	if len(queue) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s.%s", queue, name)
+}
+
+// queueDurable returns whether the queue of Listen is declared durable: the configured QueueDurable, true by default.
+func (c *lrmq) queueDurable() bool {
+	if c.config.QueueDurable == nil {
+		return true
+	}
+	return *c.config.QueueDurable
+}
+
 // Close closes the RabbitMQ channel and connection.
-// It first attempts to close the channel and logs the result.
-// If the channel closing fails, it logs an error message and exits.
-// If the channel closing succeeds, it logs a success message.
-// Then, it attempts to close the connection and logs the result.
-// If the connection closing fails, it logs an error message and exits.
-// If the connection closing succeeds, it logs a success message.
-func (c *lrmq) Close() {
-
-	if err := c.channel.Close(); err != nil {
+// It first cancels the consumers started by Listen and ListenGroup, so the broker stops sending new deliveries,
+// and waits for the deliveries already received to be processed.
+// It then closes the channels of the consumer groups, the channel and the connection, logging the result of each.
+// A failure does not prevent the next resources from being closed: the failures are logged and returned joined.
+func (c *lrmq) Close() error {
+	if !c.closed.CompareAndSwap(false, true) {
+		c.log.Info("ℹ️ Connection already closed")
+		return nil
+	}
+
+	var errs []error
+
+	if failed := c.tags.drain(); len(failed) > 0 {
+		for tag, err := range failed {
+			c.log.Errorf("❌ [%s] Failed to cancel the consumer: %+v", tag, err)
+			errs = append(errs, err)
+		}
+	} else {
+		c.log.Info("✅ Consumers successfully stopped")
+	}
+	for group, ch := range c.groups.drain() {
+		if err := ch.Close(); err != nil {
+			c.log.Infof("❌ Failed close channel of consumer group %s...", group)
+			c.log.Error(err)
+			errs = append(errs, err)
+		} else {
+			c.log.Infof("✅ Channel of consumer group %s successfully closed", group)
+		}
+	}
+
+	if err := c.ch().Close(); err != nil {
 		c.log.Info("❌ Failed close channel rabbitmq...")
-		c.log.Fatal(err)
+		c.log.Error(err)
+		errs = append(errs, err)
 	} else {
 		c.log.Info("✅ Channel successfully closed")
 	}
 
-	if err := c.connection.Close(); err != nil {
+	if err := c.conn().Close(); err != nil {
 		c.log.Info("❌ Failed close connection rabbitmq...")
-		c.log.Fatal(err)
+		c.log.Error(err)
+		errs = append(errs, err)
 	} else {
 		c.log.Info("✅ Connection successfully closed")
 	}
+
+	return errors.Join(errs...)
+}
+
+func (c *lrmq) Channel() *amqp091.Channel {
+	return c.ch()
+}
+
+func (c *lrmq) Connection() *amqp091.Connection {
+	return c.conn()
 }
 
 // NewLankyRMQ creates a new instance of LankyRMQ with the given configuration and logger.
 // If the logger is nil, a new instance of logrus.Logger will be created with the service name set to "Lanky RabbitMQ".
 // It validates the configuration parameters and logs fatal errors if any of the required parameters are empty or invalid.
-// It establishes a connection to RabbitMQ using the provided DSN and creates a channel.
+// It establishes a connection to RabbitMQ using the provided DSN, or the DSN built from the structured
+// connection fields when Dsn is empty, and creates a channel.
 // It also initializes a LankyCrypto instance with the provided secret key.
 // Returns a pointer to the created LankyRMQ instance.
+// It is the fatal wrapper of NewLankyRMQE.
 func NewLankyRMQ(
 	conf llt.LankyRabbitConf,
 	log *logrus.Logger,
@@ -375,47 +889,164 @@ func NewLankyRMQ(
 		log = llg.NewInstance(llg.SetServiceName("Lanky RabbitMQ"))
 	}
 
-	if len(strings.TrimSpace(conf.Dsn)) == 0 {
-		log.Fatal("Dsn should not be empty!")
+	rmq, err := NewLankyRMQE(conf, log)
+	if err != nil {
+		log.Fatalf("❌ %+v", err)
 	}
 
-	if len(strings.TrimSpace(conf.Secret)) == 0 {
-		log.Fatal("Secret key should not be empty")
+	return rmq
+}
+
+// NewLankyRMQE creates a new instance of LankyRMQ like NewLankyRMQ, but returns an error instead of exiting the process.
+// All the configuration problems are reported at once in a single aggregated error.
+func NewLankyRMQE(
+	conf llt.LankyRabbitConf,
+	log *logrus.Logger,
+) (LankyRMQ, error) {
+	if log == nil {
+		log = llg.NewInstance(llg.SetServiceName("Lanky RabbitMQ"))
 	}
 
-	if len(strings.TrimSpace(conf.Secret)) != 24 {
-		log.Fatal("Secret key should be 24 character long")
+	if err := validateConfig(conf); err != nil {
+		return nil, err
 	}
 
-	if len(strings.TrimSpace(conf.ExchangeName)) == 0 {
-		log.Fatal("Exchange name should not be empty")
+	dsn, err := buildDsn(conf)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rabbitmq configuration: %w", err)
 	}
 
-	if len(strings.TrimSpace(conf.ExchangeQueue)) == 0 {
-		log.Fatal("Exchange queue should not be empty")
+	var tlsConf *tls.Config
+	if isTLSDsn(dsn) {
+		if tlsConf, err = buildTLSConfig(conf); err != nil {
+			return nil, fmt.Errorf("failed to build the rabbitmq TLS configuration: %w", err)
+		}
 	}
 
-	if len(strings.TrimSpace(conf.ExchangeType)) == 0 {
-		log.Fatal("Exchange type should not be empty")
+	dialConf := newDialConfig(conf, tlsConf)
+
+	con, err := dial(dsn, dialConf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect rabbitmq %s: %w", llt.RedactDSN(dsn), err)
 	}
 
-	con, er := amqp091.Dial(conf.Dsn)
-	if er != nil {
-		log.Fatalf("❌ Failed to connect rabbitmq: %+v", er)
+	chn, err := con.Channel()
+	if err != nil {
+		con.Close()
+		return nil, fmt.Errorf("failed to create channel rabbitmq: %w", err)
 	}
 
-	chn, er := con.Channel()
-	if er != nil {
-		log.Fatalf("❌ Failed to create channel rabbitmq: %+v", er)
+	crp := newCrypto(conf)
+	if conf.DisableEncryption {
+		log.Warn("⚠️ ⚠️ ⚠️ Encryption of the rabbitmq messages is DISABLED, payloads are published in plain text. Never disable it in production ⚠️ ⚠️ ⚠️")
 	}
 
-	crp := lcp.NewLankyCrypto(conf.Secret)
+	var mtr *metrics
+	if conf.EnableMetrics {
+		if mtr, err = newMetrics(conf.MetricsRegisterer); err != nil {
+			chn.Close()
+			con.Close()
+			return nil, fmt.Errorf("failed to register rabbitmq metrics: %w", err)
+		}
+	}
 
-	return &lrmq{
+	c := &lrmq{
 		connection: con,
 		channel:    chn,
-		config:     conf,
+		config:     withNamespace(conf),
 		log:        log,
 		crp:        crp,
+		metrics:    mtr,
+		returns:    newReturnTracker(),
+		dsn:        dsn,
+		dialConf:   dialConf,
+	}
+	c.watch(con)
+
+	return c, nil
+}
+
+// newCrypto builds the encryption of the messages from the configuration, a no-op one when it is disabled.
+func newCrypto(conf llt.LankyRabbitConf) lcp.LankyCrypto {
+	if conf.DisableEncryption {
+		return lcp.NewNoOpCrypto()
 	}
+
+	crpOpts := make([]lcp.Option, 0)
+	if conf.EnableIntegrity {
+		crpOpts = append(crpOpts, lcp.WithIntegrity())
+	}
+	if conf.EnableCompression {
+		crpOpts = append(crpOpts, lcp.WithCompression())
+	}
+	if conf.EnableKeyRotation {
+		crpOpts = append(crpOpts, lcp.WithKeyRotation(conf.PreviousSecrets...))
+	}
+
+	return lcp.NewLankyCryptoWith(conf.Secret, crpOpts...)
+}
+
+// validateConfig checks the required configuration parameters and returns
+// an aggregated error listing every failing field, or nil when the configuration is valid.
+func validateConfig(conf llt.LankyRabbitConf) error {
+	var errs []error
+
+	if len(strings.TrimSpace(conf.Dsn)) == 0 && len(strings.TrimSpace(conf.Host)) == 0 {
+		errs = append(errs, errors.New("Dsn or Host should not be empty"))
+	}
+
+	if len(strings.TrimSpace(conf.Dsn)) > 0 && hasTLS(conf) && !isTLSDsn(conf.Dsn) {
+		errs = append(errs, errors.New("Dsn should use the amqps scheme when a TLS field is set"))
+	}
+
+	if conf.TLSCAFile != "" {
+		if _, err := os.Stat(conf.TLSCAFile); err != nil {
+			errs = append(errs, fmt.Errorf("TLS CA file is not accessible: %w", err))
+		}
+	}
+
+	if conf.TLSCertKeyFile != "" {
+		if _, err := os.Stat(conf.TLSCertKeyFile); err != nil {
+			errs = append(errs, fmt.Errorf("TLS certificate key file is not accessible: %w", err))
+		}
+	}
+
+	// The secret is not used when the encryption is disabled.
+	if !conf.DisableEncryption {
+		if len(strings.TrimSpace(conf.Secret)) == 0 {
+			errs = append(errs, errors.New("Secret key should not be empty"))
+		} else if n := len(strings.TrimSpace(conf.Secret)); n != 16 && n != 24 && n != 32 {
+			errs = append(errs, fmt.Errorf("Secret key should be 16, 24 or 32 character long (AES-128, AES-192 or AES-256), got %d", n))
+		}
+
+		if len(conf.PreviousSecrets) > 0 && !conf.EnableKeyRotation {
+			errs = append(errs, errors.New("Previous secrets require the key rotation to be enabled"))
+		}
+
+		for i, secret := range conf.PreviousSecrets {
+			if len(strings.TrimSpace(secret)) == 0 {
+				errs = append(errs, fmt.Errorf("Previous secret %d should not be empty", i))
+			} else if n := len(strings.TrimSpace(secret)); n != 16 && n != 24 && n != 32 {
+				errs = append(errs, fmt.Errorf("Previous secret %d should be 16, 24 or 32 character long (AES-128, AES-192 or AES-256), got %d", i, n))
+			}
+		}
+	}
+
+	if len(strings.TrimSpace(conf.ExchangeName)) == 0 {
+		errs = append(errs, errors.New("Exchange name should not be empty"))
+	}
+
+	if len(strings.TrimSpace(conf.ExchangeQueue)) == 0 && !conf.QueueExclusive {
+		errs = append(errs, errors.New("Exchange queue should not be empty unless the queue is exclusive"))
+	}
+
+	if len(strings.TrimSpace(conf.ExchangeType)) == 0 {
+		errs = append(errs, errors.New("Exchange type should not be empty"))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid rabbitmq configuration: %w", errors.Join(errs...))
+	}
+
+	return nil
 }
@@ -0,0 +1,261 @@
+package lanky_rabbitmq
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+	"github.com/sirupsen/logrus"
+	llt "github.com/the-lanky/go/types"
+)
+
+// newTestClient returns a client without connection, enough for the code paths not reaching the broker.
+func newTestClient(conf llt.LankyRabbitConf) *lrmq {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	return &lrmq{
+		config:  conf,
+		log:     log,
+		crp:     newCrypto(conf),
+		returns: newReturnTracker(),
+	}
+}
+
+// validConfig returns a configuration passing validateConfig with the given secret.
+func validConfig(secret string) llt.LankyRabbitConf {
+	return llt.LankyRabbitConf{
+		Host:          "localhost",
+		ExchangeName:  "lanky",
+		ExchangeType:  "topic",
+		ExchangeQueue: "lanky.queue",
+		Secret:        secret,
+	}
+}
+
+func TestValidateConfigSecretLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr bool
+	}{
+		{name: "AES-128", secret: strings.Repeat("a", 16)},
+		{name: "AES-192", secret: strings.Repeat("a", 24)},
+		{name: "AES-256", secret: strings.Repeat("a", 32)},
+		{name: "empty", secret: "", wantErr: true},
+		{name: "too short", secret: strings.Repeat("a", 15), wantErr: true},
+		{name: "between lengths", secret: strings.Repeat("a", 20), wantErr: true},
+		{name: "too long", secret: strings.Repeat("a", 33), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateConfig(validConfig(tt.secret))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !strings.Contains(err.Error(), "Secret key") {
+				t.Fatalf("expected the error to name the secret, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateConfigSecretIgnoredWithoutEncryption(t *testing.T) {
+	conf := validConfig("")
+	conf.DisableEncryption = true
+
+	if err := validateConfig(conf); err != nil {
+		t.Fatalf("validateConfig() error = %v, want nil", err)
+	}
+}
+
+func TestPublishCancelledContextAbortsRetries(t *testing.T) {
+	c := newTestClient(validConfig(strings.Repeat("a", 32)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	err := c.Publish(ctx, "order.created", []byte(`{"id":1}`), &LankyPublisherOption{
+		Retries:      NewRetries(10),
+		DelayRetries: time.Minute,
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Publish() error = %v, want %v", err, context.Canceled)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected Publish to abort immediately, took %s", elapsed)
+	}
+	if stats := c.Stats(); stats.Failed != 1 || !errors.Is(stats.LastError, context.Canceled) {
+		t.Fatalf("expected one failed publish with the context error, got %+v", stats)
+	}
+}
+
+// consumerFunc adapts a function to the Consumer interface.
+type consumerFunc func(msg amqp091.Delivery) error
+
+func (f consumerFunc) Consume(msg amqp091.Delivery) error { return f(msg) }
+
+func TestHandleDeduplicatesOnlyConsumedMessages(t *testing.T) {
+	conf := validConfig(strings.Repeat("a", 32))
+	conf.Deduplicator = NewMemoryDeduplicator(time.Hour)
+	c := newTestClient(conf)
+
+	var (
+		calls int
+		fail  = true
+	)
+	consumer := LankyConsumer{Raw: true, Consumer: consumerFunc(func(amqp091.Delivery) error {
+		calls++
+		if fail {
+			return errors.New("consumer failed")
+		}
+		return nil
+	})}
+	msg := amqp091.Delivery{MessageId: "42", Body: []byte("{}")}
+
+	if err := c.handle("order.created", msg, consumer); err == nil {
+		t.Fatal("expected the first delivery to fail")
+	}
+
+	// The failed message is redelivered, e.g. by Replay, and must be consumed again.
+	fail = false
+	if err := c.handle("order.created", msg, consumer); err != nil {
+		t.Fatalf("handle() error = %v", err)
+	}
+
+	// Once consumed, a redelivery is skipped.
+	if err := c.handle("order.created", msg, consumer); err != nil {
+		t.Fatalf("handle() error = %v", err)
+	}
+
+	if calls != 2 {
+		t.Fatalf("expected the consumer to be called 2 times, got %d", calls)
+	}
+}
+
+func TestAwaitReturnWithoutConfirmation(t *testing.T) {
+	tracker := newReturnTracker()
+	tracker.track("id")
+
+	// A channel replaced by a reconnect is not in confirm mode, its publishes return a nil confirmation.
+	if err := tracker.awaitReturn(context.Background(), "id", nil); !errors.Is(err, ErrNotConfirmed) {
+		t.Fatalf("expected ErrNotConfirmed, got %v", err)
+	}
+	if _, pending := tracker.pending["id"]; pending {
+		t.Fatal("expected the message to be untracked")
+	}
+}
+
+func TestHandleMessageOfThePreviousSecret(t *testing.T) {
+	var (
+		oldSecret = strings.Repeat("o", 32)
+		newSecret = strings.Repeat("n", 32)
+		payload   = []byte(`{"event":"order.created"}`)
+	)
+
+	tests := []struct {
+		name      string
+		publisher func() llt.LankyRabbitConf
+	}{
+		{
+			name: "publisher without the key rotation",
+			publisher: func() llt.LankyRabbitConf {
+				conf := validConfig(oldSecret)
+				conf.EnableIntegrity = true
+				return conf
+			},
+		},
+		{
+			name: "publisher with the key rotation",
+			publisher: func() llt.LankyRabbitConf {
+				conf := validConfig(oldSecret)
+				conf.EnableIntegrity = true
+				conf.EnableKeyRotation = true
+				return conf
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := newTestClient(tt.publisher()).crp.EncryptToBytes(payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			conf := validConfig(newSecret)
+			conf.EnableIntegrity = true
+			conf.EnableKeyRotation = true
+			conf.PreviousSecrets = []string{oldSecret}
+			if err := validateConfig(conf); err != nil {
+				t.Fatalf("validateConfig() error = %v", err)
+			}
+
+			var got []byte
+			consumer := LankyConsumer{Consumer: consumerFunc(func(msg amqp091.Delivery) error {
+				got = msg.Body
+				return nil
+			})}
+
+			if err := newTestClient(conf).handle("order.created", amqp091.Delivery{MessageId: "42", Body: body}, consumer); err != nil {
+				t.Fatalf("handle() error = %v", err)
+			}
+			if string(got) != string(payload) {
+				t.Fatalf("consumed %q, want %q", got, payload)
+			}
+		})
+	}
+}
+
+func TestValidateConfigPreviousSecretsRequireKeyRotation(t *testing.T) {
+	conf := validConfig(strings.Repeat("n", 32))
+	conf.PreviousSecrets = []string{strings.Repeat("o", 32)}
+
+	if err := validateConfig(conf); err == nil {
+		t.Fatal("expected previous secrets without the key rotation to be rejected")
+	}
+
+	conf.EnableKeyRotation = true
+	if err := validateConfig(conf); err != nil {
+		t.Fatalf("validateConfig() error = %v", err)
+	}
+}
+
+func TestDerivedQueue(t *testing.T) {
+	tests := []struct {
+		name  string
+		queue string
+		want  string
+	}{
+		{name: "named queue", queue: "orders", want: "orders.order.created"},
+		{name: "broker named queue", queue: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := derivedQueue(tt.queue, "order.created"); got != tt.want {
+				t.Errorf("derivedQueue() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckExchangeSkipsKnownExchanges(t *testing.T) {
+	conf := validConfig(strings.Repeat("a", 32))
+	c := newTestClient(conf)
+	c.exchanges.Store("audit", struct{}{})
+
+	// The client has no connection, so reaching the broker would panic.
+	for _, exchange := range []string{conf.ExchangeName, "audit"} {
+		if err := c.checkExchange(exchange); err != nil {
+			t.Errorf("checkExchange(%q) error = %v", exchange, err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package lanky_rabbitmq
+
+import (
+	"crypto/tls"
+	"sync"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+	llt "github.com/the-lanky/go/types"
+)
+
+// defaultReconnectDelay is the delay between the reconnection attempts when RejoinDelay is not set.
+const defaultReconnectDelay = time.Second * 5
+
+// restarts holds the functions starting the consumers again once the connection is restored.
+type restarts struct {
+	mu  sync.Mutex
+	fns []func() error
+}
+
+func (r *restarts) add(fn func() error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fns = append(r.fns, fn)
+}
+
+func (r *restarts) all() []func() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]func() error(nil), r.fns...)
+}
+
+// defaultLocale is the locale of the connection, like amqp091.Dial.
+const defaultLocale = "en_US"
+
+// newDialConfig builds the configuration of the connection from the heartbeat and dial timeout of the configuration
+// and the TLS config of an amqps DSN, nil for amqp. The library defaults, a 10 seconds heartbeat and a 30 seconds
+// dial timeout, apply to the settings that are not set.
+func newDialConfig(conf llt.LankyRabbitConf, tlsConf *tls.Config) amqp091.Config {
+	cfg := amqp091.Config{
+		Heartbeat:       conf.Heartbeat,
+		TLSClientConfig: tlsConf,
+		Locale:          defaultLocale,
+	}
+
+	if conf.DialTimeout > 0 {
+		cfg.Dial = amqp091.DefaultDial(conf.DialTimeout)
+	}
+
+	return cfg
+}
+
+// dial opens a connection to the broker with the given configuration.
+func dial(dsn string, cfg amqp091.Config) (*amqp091.Connection, error) {
+	return amqp091.DialConfig(dsn, cfg)
+}
+
+// current returns the channel in use with its return tracker.
+func (c *lrmq) current() (*amqp091.Channel, *returnTracker) {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+	return c.channel, c.returns
+}
+
+// ch returns the channel in use.
+func (c *lrmq) ch() *amqp091.Channel {
+	ch, _ := c.current()
+	return ch
+}
+
+// conn returns the connection in use.
+func (c *lrmq) conn() *amqp091.Connection {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+	return c.connection
+}
+
+// watch waits for the connection to be closed. When it is lost rather than closed by Close,
+// it invokes OnDisconnect and reconnects.
+func (c *lrmq) watch(con *amqp091.Connection) {
+	closed := con.NotifyClose(make(chan *amqp091.Error, 1))
+
+	go func() {
+		err, ok := <-closed
+		if !ok || err == nil || c.closed.Load() {
+			return
+		}
+
+		c.log.Errorf("❌ Connection rabbitmq lost: %+v", err)
+		if onDisconnect := c.config.OnDisconnect; onDisconnect != nil {
+			onDisconnect(err)
+		}
+
+		c.reconnect()
+	}()
+}
+
+// reconnect dials the broker every rejoin delay until it succeeds or the client is closed.
+// It then replaces the connection and the channel, starts the consumers again and invokes OnReconnect.
+func (c *lrmq) reconnect() {
+	delay := defaultReconnectDelay
+	if c.config.RejoinDelay > 0 {
+		delay = c.config.RejoinDelay
+	}
+
+	for !c.closed.Load() {
+		time.Sleep(delay)
+		c.log.Info("🛠️ Reconnecting rabbitmq service...")
+
+		con, err := dial(c.dsn, c.dialConf)
+		if err != nil {
+			c.log.Errorf("❌ Failed to reconnect rabbitmq %s: %+v", llt.RedactDSN(c.dsn), err)
+			continue
+		}
+
+		chn, err := con.Channel()
+		if err != nil {
+			con.Close()
+			c.log.Errorf("❌ Failed to create channel rabbitmq: %+v", err)
+			continue
+		}
+
+		if c.closed.Load() {
+			chn.Close()
+			con.Close()
+			return
+		}
+
+		c.connMu.Lock()
+		c.connection, c.channel, c.returns = con, chn, newReturnTracker()
+		c.connMu.Unlock()
+
+		c.groups.reset()
+		c.tags.reset()
+		c.watch(con)
+
+		for _, restart := range c.restarts.all() {
+			if err := restart(); err != nil {
+				c.log.Errorf("❌ Failed to restart a consumer after reconnection: %+v", err)
+			}
+		}
+
+		c.log.Info("✅ Connection rabbitmq restored")
+		if onReconnect := c.config.OnReconnect; onReconnect != nil {
+			onReconnect()
+		}
+		return
+	}
+}
@@ -0,0 +1,106 @@
+package lanky_rabbitmq
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// deathHeaders are the headers added by the broker when dead-lettering a message, dropped on replay.
+var deathHeaders = map[string]struct{}{
+	"x-death":                {},
+	"x-first-death-exchange": {},
+	"x-first-death-queue":    {},
+	"x-first-death-reason":   {},
+	"x-last-death-exchange":  {},
+	"x-last-death-queue":     {},
+	"x-last-death-reason":    {},
+}
+
+// Replay moves up to max messages from the dead-letter queue to the target topic of the configured exchange,
+// e.g. once the bug of a consumer is fixed. Each message is fetched on a dedicated channel, decrypted,
+// published again with Publish, which encrypts it with the current secret, and only then acknowledged.
+// The dead-lettering headers added by the broker, such as x-death, are dropped. It stops at the first failure, the failed message
+// being requeued to the dead-letter queue, and returns the number of messages replayed with the error.
+// It returns once the queue is empty or max messages were replayed.
+// Like the topics, the dead-letter queue is given without the configured namespace.
+//
+// Example usage:
+//
+//	replayed, err := rmq.Replay(ctx, "orders.dlq", "order.created", 100)
+func (c *lrmq) Replay(ctx context.Context, dlq string, target string, max int) (int, error) {
+	dlq = namespaced(c.config.Namespace, dlq)
+
+	ch, err := c.conn().Channel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to open the replay channel: %w", err)
+	}
+	defer ch.Close()
+
+	replayed := 0
+	for replayed < max {
+		if err := ctx.Err(); err != nil {
+			return replayed, err
+		}
+
+		msg, ok, err := ch.Get(dlq, false)
+		if err != nil {
+			c.log.Errorf("❌ [Q: %s] Failed to get a message to replay: %+v", dlq, err)
+			return replayed, err
+		}
+		if !ok {
+			break
+		}
+
+		if err := c.replay(ctx, msg, target); err != nil {
+			c.log.Errorf("❌ [Q: %s] [%s] Failed to replay message to topic %s: %+v", dlq, msg.MessageId, target, err)
+			if nerr := msg.Nack(false, true); nerr != nil {
+				c.log.Errorf("❌ [Q: %s] [%s] Failed to requeue message: %+v", dlq, msg.MessageId, nerr)
+			}
+			return replayed, err
+		}
+
+		if err := msg.Ack(false); err != nil {
+			c.log.Errorf("❌ [Q: %s] [%s] Failed to acknowledge replayed message: %+v", dlq, msg.MessageId, err)
+			return replayed, err
+		}
+		replayed++
+	}
+
+	c.log.Infof("✅ [Q: %s] %d message(s) replayed to topic %s", dlq, replayed, target)
+	return replayed, nil
+}
+
+// replay decrypts the dead-lettered message and publishes it to the target topic, keeping its ID and properties.
+func (c *lrmq) replay(ctx context.Context, msg amqp091.Delivery, target string) error {
+	decrypted, err := c.crp.DecryptFromBytes(msg.Body)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt message: %w", err)
+	}
+
+	var headers amqp091.Table
+	for k, v := range msg.Headers {
+		if _, ok := deathHeaders[k]; ok {
+			continue
+		}
+		if headers == nil {
+			headers = make(amqp091.Table, len(msg.Headers))
+		}
+		headers[k] = v
+	}
+
+	id := msg.MessageId
+	if len(id) == 0 {
+		id = uuid.New().String()
+	}
+
+	return c.publish(ctx, id, target, decrypted, &LankyPublisherOption{
+		ContentType:   msg.ContentType,
+		Priority:      msg.Priority,
+		Headers:       headers,
+		ReplyTo:       msg.ReplyTo,
+		CorrelationId: msg.CorrelationId,
+	}, true)
+}
@@ -0,0 +1,119 @@
+package lanky_rabbitmq
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// ErrUnroutable is returned when a mandatory message could not be routed to any queue and was returned by the broker.
+var ErrUnroutable = errors.New("message returned by the broker as unroutable")
+
+// ErrNacked is returned when the broker negatively acknowledges a published message.
+var ErrNacked = errors.New("message negatively acknowledged by the broker")
+
+// ErrNotConfirmed is returned when a message expecting a confirmation was published on a channel
+// that is not in confirm mode, e.g. a channel replaced by a reconnect during the publish.
+var ErrNotConfirmed = errors.New("message published without a confirmation from the broker")
+
+// returnBuffer is the capacity of the channel receiving the messages returned by the broker.
+const returnBuffer = 128
+
+// returnTracker correlates the messages returned by the broker with the mandatory publishes waiting for them.
+//
+// The channel is put in confirm mode the first time a mandatory message or a batch is published. The broker always sends
+// basic.return before the basic.ack of an unroutable mandatory message, and the returned message is pushed on
+// the notify channel before the confirmation is resolved, so once the confirmation arrives draining the notify
+// channel is enough to know whether the message was returned.
+type returnTracker struct {
+	once sync.Once
+	err  error
+
+	mu       sync.Mutex
+	notify   chan amqp091.Return
+	pending  map[string]struct{}
+	returned map[string]amqp091.Return
+}
+
+// newReturnTracker creates the tracker of a channel, which is not in confirm mode yet.
+func newReturnTracker() *returnTracker {
+	return &returnTracker{
+		pending:  make(map[string]struct{}),
+		returned: make(map[string]amqp091.Return),
+	}
+}
+
+// enableConfirms puts the channel of the tracker in confirm mode and registers the return notification,
+// only once per channel. The channel and the tracker must be taken together, see current, since a reconnect
+// replaces both with a channel that is not in confirm mode yet.
+func (t *returnTracker) enableConfirms(ch *amqp091.Channel) error {
+	t.once.Do(func() {
+		if err := ch.Confirm(false); err != nil {
+			t.err = err
+			return
+		}
+		t.mu.Lock()
+		t.notify = ch.NotifyReturn(make(chan amqp091.Return, returnBuffer))
+		t.mu.Unlock()
+	})
+	return t.err
+}
+
+// track marks the message id as waiting for a possible return.
+func (t *returnTracker) track(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pending[id] = struct{}{}
+}
+
+// untrack stops waiting for the message id and reports whether it was returned by the broker.
+func (t *returnTracker) untrack(id string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for drained := false; !drained; {
+		select {
+		case r, ok := <-t.notify:
+			if !ok {
+				drained = true
+				break
+			}
+			if _, isPending := t.pending[r.MessageId]; isPending {
+				t.returned[r.MessageId] = r
+			}
+		default:
+			drained = true
+		}
+	}
+
+	_, returned := t.returned[id]
+	delete(t.pending, id)
+	delete(t.returned, id)
+
+	return returned
+}
+
+// awaitReturn waits for the confirmation of a publish and turns a return or a nack into an error.
+// A nil confirmation, from a channel that is not in confirm mode, fails with ErrNotConfirmed.
+func (t *returnTracker) awaitReturn(ctx context.Context, id string, confirm *amqp091.DeferredConfirmation) error {
+	if confirm == nil {
+		t.untrack(id)
+		return ErrNotConfirmed
+	}
+
+	acked, err := confirm.WaitContext(ctx)
+	returned := t.untrack(id)
+
+	switch {
+	case err != nil:
+		return err
+	case returned:
+		return ErrUnroutable
+	case !acked:
+		return ErrNacked
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package lanky_rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// ErrNoReplyTo is returned by Reply when the request has no reply queue.
+var ErrNoReplyTo = errors.New("lanky rabbitmq: the request has no reply-to queue")
+
+// RPC publishes the message to the topic like Publish, with a correlation ID and an exclusive, server-named
+// reply queue declared on a dedicated channel, then waits for the reply carrying the same correlation ID.
+// The replies of other correlation IDs are ignored. The reply queue and its channel are removed once it returns.
+//
+// Example usage:
+//
+//	reply, err := rmq.RPC(ctx, "price.quote", request)
+//
+// and on the consumer side:
+//
+//	func (q QuoteConsumer) Consume(msg amqp091.Delivery) error {
+//	    return rmq.Reply(context.Background(), msg, quote)
+//	}
+func (c *lrmq) RPC(ctx context.Context, topic string, message []byte) ([]byte, error) {
+	ch, err := c.conn().Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the reply channel: %w", err)
+	}
+	defer ch.Close()
+
+	q, err := ch.QueueDeclare("", false, true, true, false, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to declare the reply queue: %w", err)
+	}
+
+	replies, err := ch.Consume(q.Name, "", true, true, false, false, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume the reply queue: %w", err)
+	}
+
+	correlation := uuid.New().String()
+	if err := c.Publish(ctx, topic, message, &LankyPublisherOption{
+		ReplyTo:       q.Name,
+		CorrelationId: correlation,
+	}); err != nil {
+		return nil, err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			c.log.Errorf("❌ [%s] No reply for topic %s: %+v", correlation, topic, ctx.Err())
+			return nil, ctx.Err()
+		case reply, ok := <-replies:
+			if !ok {
+				return nil, errors.New("the reply channel was closed before the reply")
+			}
+			if reply.CorrelationId != correlation {
+				continue
+			}
+
+			c.log.Infof("🔽 [%s] Reply received for topic %s", correlation, topic)
+			return c.crp.DecryptFromBytes(reply.Body)
+		}
+	}
+}
+
+func (c *lrmq) Reply(ctx context.Context, request amqp091.Delivery, message []byte) error {
+	if len(request.ReplyTo) == 0 {
+		return ErrNoReplyTo
+	}
+
+	body, err := c.crp.EncryptToBytes(message)
+	if err != nil {
+		c.log.Infof("❌ [%s] Failed reply to %s. Error message encryption!", request.CorrelationId, request.ReplyTo)
+		c.log.Error(err)
+		return err
+	}
+
+	// The reply queue is not bound to any exchange, the default exchange routes it by name.
+	if err := c.ch().PublishWithContext(ctx, "", request.ReplyTo, false, false, amqp091.Publishing{
+		ContentType:   defaultContentType,
+		MessageId:     uuid.New().String(),
+		CorrelationId: request.CorrelationId,
+		Body:          body,
+	}); err != nil {
+		c.log.Infof("❌ [%s] Failed reply to %s", request.CorrelationId, request.ReplyTo)
+		c.log.Error(err)
+		return err
+	}
+
+	c.log.Infof("✅ [%s] Success reply to %s", request.CorrelationId, request.ReplyTo)
+	return nil
+}
@@ -0,0 +1,31 @@
+package lanky_rabbitmq
+
+import (
+	"errors"
+	"fmt"
+)
+
+// defaultMaxMessageBytes is the limit of the encrypted body of a message when MaxMessageBytes is not set,
+// aligned with the default max_message_size of the broker (16 MiB since RabbitMQ 4.0).
+const defaultMaxMessageBytes = 16 << 20
+
+// ErrMessageTooLarge is returned by Publish and PublishBatch, without retrying, when the encrypted body
+// of a message exceeds MaxMessageBytes.
+var ErrMessageTooLarge = errors.New("lanky rabbitmq: message too large")
+
+// checkSize returns ErrMessageTooLarge when the encrypted body exceeds the configured limit.
+// A negative MaxMessageBytes disables the check.
+func (c *lrmq) checkSize(body []byte) error {
+	limit := c.config.MaxMessageBytes
+	if limit < 0 {
+		return nil
+	}
+	if limit == 0 {
+		limit = defaultMaxMessageBytes
+	}
+
+	if len(body) > limit {
+		return fmt.Errorf("%w: %d bytes exceed the limit of %d bytes", ErrMessageTooLarge, len(body), limit)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package lanky_rabbitmq
+
+import (
+	"sync"
+	"time"
+)
+
+// PublishStats is a snapshot of the publish health of a LankyRMQ client, returned by Stats.
+type PublishStats struct {
+	Published   uint64    // The number of messages published successfully.
+	Failed      uint64    // The number of messages that could not be published once the retries were exhausted.
+	Retries     uint64    // The number of attempts made after the first one of a message.
+	LastError   error     // The error of the last failed publish, nil if none failed.
+	LastErrorAt time.Time // The time of the last failed publish, zero if none failed.
+}
+
+// publishStats accumulates the PublishStats of a client.
+type publishStats struct {
+	mu    sync.Mutex
+	stats PublishStats
+}
+
+// record adds the outcome of a message published after the given number of attempts.
+func (s *publishStats) record(attempts Retries, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if attempts > 1 {
+		s.stats.Retries += uint64(attempts - 1)
+	}
+
+	if err == nil {
+		s.stats.Published++
+		return
+	}
+
+	s.stats.Failed++
+	s.stats.LastError = err
+	s.stats.LastErrorAt = time.Now()
+}
+
+func (s *publishStats) snapshot() PublishStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+func (c *lrmq) Stats() PublishStats {
+	return c.stats.snapshot()
+}
@@ -0,0 +1,163 @@
+package lanky_rabbitmq
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// Subscribe consumes messages of a single topic and sends them, decrypted, on the returned channel.
+// It declares the exchange and a queue named "<ExchangeQueue>.<topic>" bound to the topic, declared like
+// the queue of Listen, so several instances of the same service share the messages like they do with Listen.
+// With an exclusive queue and an empty ExchangeQueue, each subscription gets its own queue named by the broker.
+// Messages that fail to be decrypted are logged and dropped.
+// When the connection is lost, the subscription is resumed on the restored connection, every rejoin delay until it
+// succeeds, and the returned channel stays open. The messages published in between are only kept by a durable queue.
+// When the context is cancelled the AMQP consumer is cancelled and the returned channel is closed.
+// Close cancels the consumer too, and waits for the delivery being sent to be received before closing the channel.
+//
+// Example:
+//
+//	messages, err := rmq.Subscribe(ctx, "order.created")
+//	if err != nil {
+//	    return err
+//	}
+//	for msg := range messages {
+//	    handle(msg.Body)
+//	}
+func (c *lrmq) Subscribe(ctx context.Context, topic string) (<-chan amqp091.Delivery, error) {
+	if err := c.declareExchange(); err != nil {
+		c.log.Errorf("❌ [E: %s] [T: %s] Subscriber failed to declare an exchange", c.config.ExchangeName, topic)
+		return nil, err
+	}
+
+	deliveries, tag, err := c.consumeTopic(topic, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan amqp091.Delivery)
+
+	go func() {
+		defer c.tags.done()
+		defer close(out)
+
+		for {
+			interrupted := c.forward(ctx, topic, deliveries, out)
+			c.tags.cancel(tag)
+			if !interrupted || c.closed.Load() {
+				return
+			}
+
+			c.log.Warnf("⚠️ [E: %s] [T: %s] Subscription interrupted, resubscribing...", c.config.ExchangeName, topic)
+			if deliveries, tag, err = c.resubscribe(ctx, topic); err != nil {
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// consumeTopic declares the queue of the topic on the channel in use, binds it and consumes it,
+// registering the consumer tag with the given number of processing goroutines.
+func (c *lrmq) consumeTopic(topic string, workers int) (<-chan amqp091.Delivery, string, error) {
+	ch := c.ch()
+
+	q, err := ch.QueueDeclare(
+		derivedQueue(c.config.ExchangeQueue, topic),
+		c.queueDurable(),
+		c.config.QueueAutoDelete,
+		c.config.QueueExclusive,
+		false,
+		c.queueArgs(),
+	)
+	if err != nil {
+		c.log.Errorf("❌ [E: %s] [T: %s] Subscriber failed to declare a queue", c.config.ExchangeName, topic)
+		return nil, "", err
+	}
+
+	if err = ch.QueueBind(q.Name, c.routingKey(topic), c.config.ExchangeName, false, nil); err != nil {
+		c.log.Errorf("❌ [E: %s] [Q: %s] Subscriber failed to bind topic %s", c.config.ExchangeName, q.Name, topic)
+		return nil, "", err
+	}
+
+	tag := uuid.New().String()
+
+	deliveries, err := ch.Consume(q.Name, tag, true, false, false, false, nil)
+	if err != nil {
+		c.log.Errorf("❌ [E: %s] [Q: %s] Subscriber failed to consume topic %s", c.config.ExchangeName, q.Name, topic)
+		return nil, "", err
+	}
+
+	c.tags.add(ch, tag, workers)
+
+	c.log.Infof("✨ [E: %s] [Q: %s] Subscribed to topic: %s", c.config.ExchangeName, q.Name, topic)
+
+	return deliveries, tag, nil
+}
+
+// resubscribe subscribes to the topic again every rejoin delay, until it succeeds on the restored connection,
+// the context is done or the client is closed.
+func (c *lrmq) resubscribe(ctx context.Context, topic string) (<-chan amqp091.Delivery, string, error) {
+	delay := defaultReconnectDelay
+	if c.config.RejoinDelay > 0 {
+		delay = c.config.RejoinDelay
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, "", ctx.Err()
+		case <-time.After(delay):
+		}
+
+		if c.closed.Load() {
+			return nil, "", fmt.Errorf("subscription to topic %s stopped: the client is closed", topic)
+		}
+
+		// The deliveries are processed by the goroutine already running, no new one is registered.
+		if deliveries, tag, err := c.consumeTopic(topic, 0); err == nil {
+			return deliveries, tag, nil
+		}
+	}
+}
+
+// forward decrypts the deliveries and sends them on the output channel. It returns true once the deliveries
+// are closed, e.g. when the connection is lost, and false when the context is done.
+func (c *lrmq) forward(ctx context.Context, topic string, deliveries <-chan amqp091.Delivery, out chan<- amqp091.Delivery) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case msg, ok := <-deliveries:
+			if !ok {
+				return true
+			}
+
+			c.metrics.consume(topic)
+
+			decrypted, err := c.crp.DecryptFromBytes(msg.Body)
+			if err != nil {
+				c.log.Errorf(`❌ [%s] [%s] Failed to decrypt message: %v`, msg.MessageId, topic, err)
+				c.metrics.consumeError(topic)
+				continue
+			}
+
+			if c.config.EnableDebugMessage {
+				c.log.Debug(string(decrypted))
+			}
+
+			msg.Body = decrypted
+
+			select {
+			case out <- msg:
+			case <-ctx.Done():
+				return false
+			}
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package lanky_rabbitmq
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// Subscription represents the registration of a consumer to a topic, with its own consumption settings.
+type Subscription struct {
+	// Topic is the binding key of the subscription. On a topic exchange it can be a pattern,
+	// e.g. order.*.created or order.#.
+	Topic string
+
+	Consumer Consumer
+
+	// AutoAck acknowledges the deliveries as soon as they are received. Otherwise each delivery is
+	// acknowledged once consumed, and rejected without requeue when it cannot be decrypted or consumed,
+	// so it is dead-lettered when the queue has a dead letter exchange.
+	AutoAck bool
+
+	// Concurrency is the number of deliveries processed in parallel. Defaults to 1.
+	// Without AutoAck, it is also the prefetch count of the channel.
+	Concurrency int
+
+	// OnError is invoked like LankyConsumer.OnError. It is optional.
+	OnError func(topic string, msg amqp091.Delivery, err error)
+
+	// Raw passes the body to the consumer without decrypting it, like LankyConsumer.Raw.
+	Raw bool
+}
+
+// ListenSubscriptions starts consuming every subscription on a dedicated channel sharing the connection.
+// Each subscription consumes its own queue named "<ExchangeQueue>.<Topic>" bound to its topic, declared like
+// the queue of Listen, with Concurrency goroutines processing the deliveries. With an exclusive queue and an
+// empty ExchangeQueue, the broker names it. A panic of a consumer is recovered and the
+// delivery rejected, without stopping the subscription. The consumers are stopped by Close.
+//
+// The subscriptions are validated before any is started. It returns the error of the first subscription
+// that fails to start, the previous ones being left running.
+//
+// Example:
+//
+//	err := rmq.ListenSubscriptions([]Subscription{
+//	    {Topic: "order.*.created", Consumer: OrderCreatedConsumer{}, Concurrency: 4},
+//	})
+func (c *lrmq) ListenSubscriptions(subscriptions []Subscription) error {
+	var errs []error
+	for i, sub := range subscriptions {
+		if len(sub.Topic) == 0 {
+			errs = append(errs, fmt.Errorf("subscription %d: Topic should not be empty", i))
+		}
+		if sub.Consumer == nil {
+			errs = append(errs, fmt.Errorf("subscription %d: Consumer should not be nil", i))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		c.log.Errorf("❌ [E: %s] Invalid subscriptions: %+v", c.config.ExchangeName, err)
+		return err
+	}
+
+	if err := c.declareExchange(); err != nil {
+		c.log.Errorf("❌ [E: %s] Consumer failed to declare an exchange: %+v", c.config.ExchangeName, err)
+		return err
+	}
+
+	for _, sub := range subscriptions {
+		if err := c.subscribe(sub); err != nil {
+			c.log.Errorf("❌ [E: %s] [T: %s] %+v", c.config.ExchangeName, sub.Topic, err)
+			return err
+		}
+
+		c.restarts.add(func() error {
+			if err := c.declareExchange(); err != nil {
+				return err
+			}
+			return c.subscribe(sub)
+		})
+	}
+
+	return nil
+}
+
+// subscribe opens the channel of the subscription, declares and binds its queue and starts its workers.
+func (c *lrmq) subscribe(sub Subscription) error {
+	group := "subscription " + sub.Topic
+
+	ch, err := c.groups.open(c.conn(), group)
+	if err != nil {
+		return err
+	}
+
+	fail := func(format string, err error) error {
+		c.groups.release(group)
+		return fmt.Errorf(format, err)
+	}
+
+	workers := sub.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+
+	if !sub.AutoAck {
+		if err := ch.Qos(workers, 0, false); err != nil {
+			return fail("Consumer failed to set the prefetch count: %w", err)
+		}
+	}
+
+	q, err := ch.QueueDeclare(
+		derivedQueue(c.config.ExchangeQueue, sub.Topic),
+		c.queueDurable(),
+		c.config.QueueAutoDelete,
+		c.config.QueueExclusive,
+		false,
+		c.queueArgs(),
+	)
+	if err != nil {
+		return fail("Consumer failed to declare a queue: %w", err)
+	}
+
+	if err := ch.QueueBind(q.Name, c.routingKey(sub.Topic), c.config.ExchangeName, false, nil); err != nil {
+		return fail("Consumer failed to bind the queue: %w", err)
+	}
+
+	tag := uuid.New().String()
+
+	deliveries, err := ch.Consume(q.Name, tag, sub.AutoAck, false, false, false, nil)
+	if err != nil {
+		return fail("Consumer failed to consume message: %w", err)
+	}
+
+	c.tags.add(ch, tag, workers)
+
+	consumer := LankyConsumer{Consumer: sub.Consumer, OnError: sub.OnError, Raw: sub.Raw}
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer c.tags.done()
+
+			for msg := range deliveries {
+				c.logMessagef(
+					"🔽 [E: %s] [Q: %s] [%s] Consume topic %s",
+					c.config.ExchangeName,
+					q.Name,
+					msg.MessageId,
+					c.topicOf(msg.RoutingKey),
+				)
+				c.handleSubscription(msg, consumer, sub.AutoAck)
+			}
+		}()
+	}
+
+	c.log.Infof(
+		"✅ [E: %s] [Q: %s] Rabbit consumer started for topic %s with %d worker(s)...",
+		c.config.ExchangeName,
+		q.Name,
+		sub.Topic,
+		workers,
+	)
+
+	return nil
+}
+
+// handleSubscription handles a delivery of a subscription, recovering from a panic of its consumer,
+// and acknowledges or rejects it unless it was auto acknowledged.
+func (c *lrmq) handleSubscription(msg amqp091.Delivery, consumer LankyConsumer, autoAck bool) {
+	err := c.handleSafely(c.topicOf(msg.RoutingKey), msg, consumer)
+	if autoAck {
+		return
+	}
+
+	if err != nil {
+		err = msg.Reject(false)
+	} else {
+		err = msg.Ack(false)
+	}
+	if err != nil {
+		c.log.Errorf("❌ [%s] [%s] Failed to acknowledge message: %+v", msg.MessageId, msg.RoutingKey, err)
+	}
+}
@@ -0,0 +1,75 @@
+package lanky_rabbitmq
+
+import (
+	"sync"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// consumerTags tracks the consumer tags started by Listen and ListenGroup with their channel,
+// and the goroutines processing their deliveries, so Close can stop them gracefully.
+type consumerTags struct {
+	mu      sync.Mutex
+	tags    map[string]*amqp091.Channel
+	running sync.WaitGroup
+}
+
+// add registers the tag consumed on the channel and its processing goroutines.
+func (t *consumerTags) add(ch *amqp091.Channel, tag string, workers int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.tags == nil {
+		t.tags = make(map[string]*amqp091.Channel)
+	}
+	t.tags[tag] = ch
+	t.running.Add(workers)
+}
+
+// done marks a processing goroutine of a tag as finished.
+func (t *consumerTags) done() {
+	t.running.Done()
+}
+
+// cancel stops the deliveries of the tag, if it is still registered.
+func (t *consumerTags) cancel(tag string) error {
+	t.mu.Lock()
+	ch, ok := t.tags[tag]
+	delete(t.tags, tag)
+	t.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	return ch.Cancel(tag, false)
+}
+
+// reset forgets every tag without cancelling it, e.g. once its connection is lost. The processing goroutines
+// finish on their own as their deliveries are closed.
+func (t *consumerTags) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tags = nil
+}
+
+// drain cancels every registered tag, so the broker stops sending new deliveries, and waits for the processing
+// goroutines to handle the deliveries already received. It returns the tags that could not be cancelled.
+func (t *consumerTags) drain() map[string]error {
+	t.mu.Lock()
+	tags := t.tags
+	t.tags = nil
+	t.mu.Unlock()
+
+	failed := make(map[string]error)
+	for tag, ch := range tags {
+		if err := ch.Cancel(tag, false); err != nil {
+			failed[tag] = err
+		}
+	}
+
+	if len(failed) == 0 {
+		t.running.Wait()
+	}
+
+	return failed
+}
@@ -0,0 +1,66 @@
+package lanky_rabbitmq
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"os"
+	"strings"
+
+	llt "github.com/the-lanky/go/types"
+)
+
+// defaultTLSPort is the AMQPS port used when the configuration enables TLS without setting a port.
+const defaultTLSPort = 5671
+
+// hasTLS reports whether one of the TLS fields of the configuration is set.
+func hasTLS(conf llt.LankyRabbitConf) bool {
+	return conf.TLSConfig != nil || conf.TLSCAFile != "" || conf.TLSCertKeyFile != "" || conf.TLSInsecure
+}
+
+// isTLSDsn reports whether the DSN uses the amqps scheme.
+func isTLSDsn(dsn string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(dsn)), "amqps://")
+}
+
+// buildTLSConfig builds the tls.Config used to dial an amqps DSN from the TLS fields of the configuration.
+// A TLSConfig set in the configuration is used as is. Otherwise the CA file is used as the root pool
+// to verify the broker, and the certificate key file is expected to contain both the client certificate
+// and its private key. Without any TLS field, the returned config verifies the broker with the system roots.
+func buildTLSConfig(conf llt.LankyRabbitConf) (*tls.Config, error) {
+	if conf.TLSConfig != nil {
+		return conf.TLSConfig, nil
+	}
+
+	tlsConf := &tls.Config{
+		InsecureSkipVerify: conf.TLSInsecure,
+	}
+
+	if conf.TLSCAFile != "" {
+		ca, err := os.ReadFile(conf.TLSCAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.New("no valid certificate found in the TLS CA file")
+		}
+		tlsConf.RootCAs = pool
+	}
+
+	if conf.TLSCertKeyFile != "" {
+		pem, err := os.ReadFile(conf.TLSCertKeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		cert, err := tls.X509KeyPair(pem, pem)
+		if err != nil {
+			return nil, err
+		}
+		tlsConf.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConf, nil
+}
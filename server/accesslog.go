@@ -0,0 +1,97 @@
+package lanky_server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	llog "github.com/the-lanky/go/log"
+)
+
+// responseRecorder records the status and the number of bytes of the response written through it.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if rr.status == 0 {
+		rr.status = status
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+	}
+	n, err := rr.ResponseWriter.Write(b)
+	rr.bytes += n
+	return n, err
+}
+
+// Flush flushes the response when the underlying writer supports it, e.g. for server-sent events.
+func (rr *responseRecorder) Flush() {
+	if f, ok := rr.ResponseWriter.(http.Flusher); ok {
+		if rr.status == 0 {
+			rr.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying writer, so http.ResponseController reaches its optional methods.
+func (rr *responseRecorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
+
+// AccessLog returns a middleware logging a line per request with its method, path, status, latency,
+// number of bytes written and request ID. A 5xx response is logged at Warn level, any other at Info level.
+// The request ID is the one set by the RequestID middleware, whether it runs before or after AccessLog.
+// If the logger is nil, a new instance of llog is created.
+//
+// Example usage:
+//
+//	handler = lanky_server.AccessLog(log)(lanky_server.RequestID(handler))
+func AccessLog(log *logrus.Logger) func(http.Handler) http.Handler {
+	if log == nil {
+		log = llog.NewInstance(llog.SetServiceName("API Service"))
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var (
+				start = time.Now()
+				rec   = &responseRecorder{ResponseWriter: w}
+			)
+
+			next.ServeHTTP(rec, r)
+
+			status := rec.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			id := llog.RequestIDFromContext(r.Context())
+			if len(id) == 0 {
+				id = w.Header().Get(RequestIDHeader)
+			}
+
+			entry := log.WithContext(r.Context()).WithFields(logrus.Fields{
+				"method":            r.Method,
+				"path":              r.URL.Path,
+				"status":            status,
+				"latency":           time.Since(start).String(),
+				"bytes":             rec.bytes,
+				llog.RequestIDField: id,
+			})
+
+			if status >= http.StatusInternalServerError {
+				entry.Warnf("[🌐] %s %s %d", r.Method, r.URL.Path, status)
+				return
+			}
+			entry.Infof("[🌐] %s %s %d", r.Method, r.URL.Path, status)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package lanky_server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	ltp "github.com/the-lanky/go/types"
+)
+
+var (
+	defaultCorsMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodHead}
+	defaultCorsHeaders = []string{"Accept", "Authorization", "Content-Type", RequestIDHeader}
+)
+
+// Cors returns a middleware handling the cross-origin requests according to the configuration.
+// It answers the preflight OPTIONS requests itself and sets the Access-Control-* headers on the
+// requests of the allowed origins. The requests of the other origins are passed through without
+// those headers, so the browser blocks them.
+// The allowed origin is echoed rather than "*" when credentials are allowed, as browsers require.
+//
+// Example usage:
+//
+//	handler = lanky_server.Cors(ltp.LankyCorsConf{AllowedOrigins: []string{"https://app.example.com"}})(handler)
+func Cors(conf ltp.LankyCorsConf) func(http.Handler) http.Handler {
+	var (
+		anyOrigin = len(conf.AllowedOrigins) == 0
+		origins   = make(map[string]struct{}, len(conf.AllowedOrigins))
+		methods   = defaultCorsMethods
+		headers   = defaultCorsHeaders
+		maxAge    string
+	)
+
+	for _, origin := range conf.AllowedOrigins {
+		if origin == "*" {
+			anyOrigin = true
+		}
+		origins[strings.ToLower(origin)] = struct{}{}
+	}
+
+	if len(conf.AllowedMethods) > 0 {
+		methods = conf.AllowedMethods
+	}
+
+	if len(conf.AllowedHeaders) > 0 {
+		headers = conf.AllowedHeaders
+	}
+
+	if conf.MaxAge > 0 {
+		maxAge = strconv.Itoa(int(conf.MaxAge.Seconds()))
+	}
+
+	allowMethods := strings.Join(methods, ", ")
+	allowHeaders := strings.Join(headers, ", ")
+	exposeHeaders := strings.Join(conf.ExposedHeaders, ", ")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if len(origin) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Add("Vary", "Origin")
+
+			if _, ok := origins[strings.ToLower(origin)]; !ok && !anyOrigin {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if anyOrigin && !conf.AllowCredentials {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+
+			if conf.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			preflight := r.Method == http.MethodOptions && len(r.Header.Get("Access-Control-Request-Method")) > 0
+			if !preflight {
+				if len(exposeHeaders) > 0 {
+					h.Set("Access-Control-Expose-Headers", exposeHeaders)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			h.Set("Access-Control-Allow-Methods", allowMethods)
+			h.Set("Access-Control-Allow-Headers", allowHeaders)
+			if len(maxAge) > 0 {
+				h.Set("Access-Control-Max-Age", maxAge)
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
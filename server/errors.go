@@ -0,0 +1,91 @@
+package lanky_server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/sirupsen/logrus"
+	lerr "github.com/the-lanky/go/errors"
+	llog "github.com/the-lanky/go/log"
+)
+
+// WriteError writes the error as a JSON body with the matching HTTP status.
+// A *LankyHttpCommonError is written with its HTTP status, a *LankyCommonError with the status registered
+// for its code, see GetHttpStatus. Any other error is written as the registered UnidentifiedError with a 500,
+// so its message never reaches the client.
+// The body is always the ErrorResponse of the error, see Response, whatever its type.
+//
+// Example usage:
+//
+//	if err := svc.Do(r.Context()); err != nil {
+//	    lanky_server.WriteError(w, err)
+//	    return
+//	}
+func WriteError(w http.ResponseWriter, err error) {
+	var (
+		status int
+		body   any
+		httpE  *lerr.LankyHttpCommonError
+		commE  *lerr.LankyCommonError
+	)
+
+	switch {
+	case errors.As(err, &httpE):
+		status, body = httpE.LankyCommonError.Response()
+		if httpE.HttpStatusNumber != 0 {
+			status = httpE.HttpStatusNumber
+		}
+	case errors.As(err, &commE):
+		status, body = commE.Response()
+	default:
+		_, body = lerr.New(lerr.UnidentifiedError, nil).Response()
+		status = http.StatusInternalServerError
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// Recovery returns a middleware recovering from the panics of the handler. The panic is logged with its stack
+// and the response is the registered UnidentifiedError with a 500 status, written by WriteError.
+// The stack is only sent back as the data of the error when not in production.
+// The http.ErrAbortHandler panic, used to abort a response on purpose, is propagated.
+// If the logger is nil, a new instance of llog is created.
+//
+// Example usage:
+//
+//	handler = lanky_server.Recovery(log, isProduction)(handler)
+func Recovery(log *logrus.Logger, isProduction bool) func(http.Handler) http.Handler {
+	if log == nil {
+		log = llog.NewInstance(llog.SetServiceName("API Service"))
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				stack := string(debug.Stack())
+				log.WithContext(r.Context()).Errorf("[❌] Panic on %s %s: %v\n%s", r.Method, r.URL.Path, rec, stack)
+
+				lce := lerr.New(lerr.UnidentifiedError, nil)
+				if !isProduction {
+					lce.SetSystemMessage(fmt.Sprintf("%v\n%s", rec, stack))
+				}
+				WriteError(w, lce)
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
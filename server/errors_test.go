@@ -0,0 +1,80 @@
+package lanky_server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	lerr "github.com/the-lanky/go/errors"
+)
+
+func TestWriteErrorEnvelope(t *testing.T) {
+	const notFound lerr.LankyErrorCode = 42
+
+	lerr.Register(
+		map[lerr.LankyErrorCode]*lerr.LankyCommonError{
+			notFound: {ClientMessage: "Not found", SystemMessage: "record not found", Code: notFound},
+		},
+		map[lerr.LankyErrorCode]int{notFound: http.StatusNotFound},
+	)
+
+	envelope := map[string]any{"message": "Not found", "data": "record not found", "code": float64(notFound)}
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantBody   map[string]any
+	}{
+		{
+			name:       "common error",
+			err:        lerr.New(notFound, nil),
+			wantStatus: http.StatusNotFound,
+			wantBody:   envelope,
+		},
+		{
+			name:       "http error",
+			err:        lerr.New(notFound, nil).ToHttpStatusError(),
+			wantStatus: http.StatusNotFound,
+			wantBody:   envelope,
+		},
+		{
+			name:       "http error with its own status",
+			err:        &lerr.LankyHttpCommonError{LankyCommonError: *lerr.New(notFound, nil), HttpStatusNumber: http.StatusGone},
+			wantStatus: http.StatusGone,
+			wantBody:   envelope,
+		},
+		{
+			name:       "plain error",
+			err:        errors.New("secret detail"),
+			wantStatus: http.StatusInternalServerError,
+			wantBody: map[string]any{
+				"message": "Unidentified error has occured. Please contact our dev",
+				"data":    "Internal server error",
+				"code":    float64(lerr.UnidentifiedError),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			WriteError(rec, tt.err)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+
+			var got map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("invalid JSON body %q: %v", rec.Body.String(), err)
+			}
+			if !reflect.DeepEqual(got, tt.wantBody) {
+				t.Errorf("body = %v, want %v", got, tt.wantBody)
+			}
+		})
+	}
+}
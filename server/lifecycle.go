@@ -0,0 +1,123 @@
+package lanky_server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	llog "github.com/the-lanky/go/log"
+)
+
+// shutdownSignals are the signals triggering the graceful shutdown.
+var shutdownSignals = []os.Signal{
+	os.Interrupt,
+	syscall.SIGHUP,
+	syscall.SIGINT,
+	syscall.SIGTERM,
+	syscall.SIGQUIT,
+}
+
+// component is a resource registered on a Lifecycle.
+type component struct {
+	name  string
+	close func(ctx context.Context) error
+}
+
+// Lifecycle closes the components of an application, e.g. the RabbitMQ client and the database connections,
+// in the reverse order of their registration, so a component is closed before the ones it depends on.
+// The server returned by New owns a Lifecycle, closed once the HTTP server has stopped.
+//
+// Example usage:
+//
+//	server := lanky_server.New(handler, conf, log)
+//	server.Lifecycle().Register("postgres", pg)
+//	server.Lifecycle().Register("rabbitmq", rmq)
+//	server.Start(ctx, make(chan os.Signal, 1))
+type Lifecycle struct {
+	mu         sync.Mutex
+	components []component
+	log        *logrus.Logger
+}
+
+// NewLifecycle creates an empty Lifecycle. If the logger is nil, a new instance of llog is created.
+func NewLifecycle(log *logrus.Logger) *Lifecycle {
+	if log == nil {
+		log = llog.NewInstance(llog.SetServiceName("Lifecycle"))
+	}
+	return &Lifecycle{log: log}
+}
+
+// Register adds a component closed on shutdown, such as LankyRMQ, LankyPostgre or LankyMongo.
+func (l *Lifecycle) Register(name string, closer io.Closer) {
+	l.RegisterFunc(name, func(context.Context) error {
+		return closer.Close()
+	})
+}
+
+// RegisterFunc adds a component closed on shutdown by the given function, e.g. the CloseCtx method of a driver.
+func (l *Lifecycle) RegisterFunc(name string, close func(ctx context.Context) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.components = append(l.components, component{name: name, close: close})
+}
+
+// Shutdown closes the components in the reverse order of their registration within the deadline of the context.
+// A failure does not prevent the next components from being closed. Once the context is done, the components
+// not closed yet are skipped. The failures are logged and returned joined.
+func (l *Lifecycle) Shutdown(ctx context.Context) error {
+	l.mu.Lock()
+	components := l.components
+	l.components = nil
+	l.mu.Unlock()
+
+	var errs []error
+	for i := len(components) - 1; i >= 0; i-- {
+		cmp := components[i]
+		if err := closeComponent(ctx, cmp); err != nil {
+			l.log.Errorf("[❌] Failed to close %s: %+v", cmp.name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", cmp.name, err))
+			continue
+		}
+		l.log.Infof("[✅] %s successfully closed", cmp.name)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Run waits for a shutdown signal on the channel, then closes the components within the given timeout.
+// It is meant for applications without an HTTP server, e.g. a worker only consuming RabbitMQ.
+func (l *Lifecycle) Run(ctx context.Context, timeout time.Duration, close chan os.Signal) error {
+	signal.Notify(close, shutdownSignals...)
+	<-close
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return l.Shutdown(ctx)
+}
+
+// closeComponent closes the component, returning the context error when it does not finish before the context is done.
+func closeComponent(ctx context.Context, cmp component) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmp.close(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
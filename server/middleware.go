@@ -0,0 +1,48 @@
+package lanky_server
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	llog "github.com/the-lanky/go/log"
+)
+
+// RequestIDHeader is the header carrying the request ID across services.
+const RequestIDHeader = "X-Request-Id"
+
+// RequestID is a middleware that propagates the request ID.
+// It reads the ID from the X-Request-Id header, or generates a UUID when the header is empty,
+// stores it in the request context and echoes it in the response header.
+// Handlers can then log with lanky_logger.WithRequestID(r.Context()) or log.WithContext(r.Context()),
+// the former through the logger stored in the context by ContextLogger, which New applies to every request.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if len(id) == 0 {
+			id = uuid.New().String()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(llog.ContextWithRequestID(r.Context(), id)))
+	})
+}
+
+// ContextLogger returns a middleware storing the logger in the request context, so lanky_logger.WithRequestID
+// and lanky_logger.FromContext log through it, with its hooks and formatter, instead of the logrus standard logger.
+// New applies it with the logger of the server. If the logger is nil, a new instance of llog is created.
+//
+// Example usage:
+//
+//	handler = lanky_server.ContextLogger(log)(lanky_server.RequestID(handler))
+func ContextLogger(log *logrus.Logger) func(http.Handler) http.Handler {
+	if log == nil {
+		log = llog.NewInstance(llog.SetServiceName("API Service"))
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(llog.ContextWithLogger(r.Context(), log)))
+		})
+	}
+}
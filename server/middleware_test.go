@@ -0,0 +1,42 @@
+package lanky_server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	llog "github.com/the-lanky/go/log"
+	ltp "github.com/the-lanky/go/types"
+)
+
+func TestRequestIDLogsThroughTheServerLogger(t *testing.T) {
+	var buf bytes.Buffer
+
+	log := logrus.New()
+	log.SetOutput(&buf)
+	log.SetFormatter(&logrus.JSONFormatter{})
+
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		llog.WithRequestID(r.Context()).Info("Order created")
+	}))
+
+	s := New(handler, ltp.LankyServerConf{}, log).(*ls)
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	s.server.Handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("expected the entry to be logged by the server logger, got %q: %v", buf.String(), err)
+	}
+	if line["msg"] != "Order created" {
+		t.Errorf("msg = %v, want %q", line["msg"], "Order created")
+	}
+	if line[llog.RequestIDField] != "req-1" {
+		t.Errorf("%s = %v, want %q", llog.RequestIDField, line[llog.RequestIDField], "req-1")
+	}
+}
@@ -0,0 +1,43 @@
+package lanky_server
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+)
+
+// defaultProfilingPathPrefix is the path the profiling endpoints are mounted on when no prefix is configured.
+const defaultProfilingPathPrefix = "/debug/pprof"
+
+// profilingPathPrefix normalizes the configured prefix to a leading slash without a trailing one,
+// falling back to defaultProfilingPathPrefix when it is empty.
+func profilingPathPrefix(prefix string) string {
+	prefix = "/" + strings.Trim(prefix, "/")
+	if prefix == "/" {
+		return defaultProfilingPathPrefix
+	}
+	return prefix
+}
+
+// withProfiling mounts the pprof endpoints on prefix, and the expvar variables on prefix/vars,
+// ahead of the given handler, which keeps serving every other path.
+func withProfiling(handler http.Handler, prefix string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(prefix+"/cmdline", pprof.Cmdline)
+	mux.HandleFunc(prefix+"/profile", pprof.Profile)
+	mux.HandleFunc(prefix+"/symbol", pprof.Symbol)
+	mux.HandleFunc(prefix+"/trace", pprof.Trace)
+	mux.Handle(prefix+"/vars", expvar.Handler())
+	mux.HandleFunc(prefix+"/", func(w http.ResponseWriter, r *http.Request) {
+		// pprof.Index only resolves the named profiles under /debug/pprof/, so serve them by name.
+		if name := strings.TrimPrefix(r.URL.Path, prefix+"/"); len(name) > 0 {
+			pprof.Handler(name).ServeHTTP(w, r)
+			return
+		}
+		pprof.Index(w, r)
+	})
+	mux.Handle("/", handler)
+
+	return mux
+}
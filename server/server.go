@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
-	"syscall"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -20,6 +22,25 @@ type LankyServer interface {
 	// Start starts the server.
 	// It takes a context.Context and a channel to receive an os.Signal to gracefully shut down the server.
 	Start(ctx context.Context, close chan os.Signal)
+
+	// Serve starts the server on the listener given to NewWithListener, and shuts it down gracefully like Start.
+	// Without a listener, it listens on the configured address.
+	Serve(ctx context.Context, close chan os.Signal)
+
+	// TrackGoroutine registers a background goroutine spawned while handling a request
+	// and returns the function to call once it is done.
+	// On shutdown the server waits for the tracked goroutines, within the shutdown delay, after the HTTP server has stopped.
+	//
+	//	done := server.TrackGoroutine()
+	//	go func() {
+	//	    defer done()
+	//	    // async work
+	//	}()
+	TrackGoroutine() (done func())
+
+	// Lifecycle returns the components closed on shutdown, in the reverse order of their registration,
+	// once the HTTP server and the tracked goroutines have stopped, within a shutdown delay of their own.
+	Lifecycle() *Lifecycle
 }
 
 // Start starts the server and runs the API service.
@@ -46,21 +67,50 @@ func (s *ls) Start(ctx context.Context, close chan os.Signal) {
 	s.gracefullShutdown(ctx, close)
 }
 
+// Serve serves the API service on the listener given to NewWithListener, e.g. an ephemeral port in tests
+// or a socket passed by systemd, and gracefully shuts it down when a signal is received, like Start.
+// Without a listener, it listens on the configured address.
+//
+// Parameters:
+//   - ctx: The context.Context object for managing the server's lifecycle.
+//   - close: The channel to receive a signal for stopping the service.
+func (s *ls) Serve(ctx context.Context, close chan os.Signal) {
+	ln := s.listener
+	if ln == nil {
+		var err error
+		if ln, err = net.Listen("tcp", s.server.Addr); err != nil {
+			s.log.Fatalf("[❌] Failed start API Service: %+v", err)
+		}
+	}
+
+	apiFn := func() {
+		err := s.server.Serve(ln)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.log.Fatalf("[❌] Failed start API Service: %+v", err)
+		}
+	}
+
+	go apiFn()
+
+	s.log.Infof("[🚀] API run on http://%s", ln.Addr())
+	s.log.Info("[✨] Press CTRL+C to stop the service")
+	s.gracefullShutdown(ctx, close)
+}
+
 // gracefullShutdown gracefully shuts down the server.
 // It listens for the specified signals and waits for one of them to be received.
 // Upon receiving a signal, it sets the server's keep-alive flag to false,
 // creates a context with a timeout using the specified shutdown delay,
 // and attempts to gracefully shut down the server using the Shutdown method.
+// While the server drains, the number of open connections is logged every second.
+// When they are still open at the deadline, they are forced closed.
+// Once the server has stopped, it waits for the goroutines registered with TrackGoroutine within the same deadline,
+// then closes the components registered on the Lifecycle within another shutdown delay, even when the server failed to stop.
+// The OnShutdownStart hook is invoked before Shutdown and the OnShutdownComplete hook right after it returns,
+// once the remaining connections are forced closed, before waiting for the goroutines and closing the components.
 // It then builds and logs a message indicating whether the shutdown was successful or not.
 func (s *ls) gracefullShutdown(ctx context.Context, close chan os.Signal) {
-	signal.Notify(
-		close,
-		os.Interrupt,
-		syscall.SIGHUP,
-		syscall.SIGINT,
-		syscall.SIGTERM,
-		syscall.SIGQUIT,
-	)
+	signal.Notify(close, shutdownSignals...)
 	<-close
 
 	ctx, cancel := context.WithTimeout(ctx, s.conf.ShutdownDelay)
@@ -68,7 +118,35 @@ func (s *ls) gracefullShutdown(ctx context.Context, close chan os.Signal) {
 
 	s.server.SetKeepAlivesEnabled(false)
 
+	if s.conf.OnShutdownStart != nil {
+		s.conf.OnShutdownStart(ctx)
+	}
+
+	stopReport := s.reportDraining(ctx)
 	err := s.server.Shutdown(ctx)
+	stopReport()
+
+	if err != nil {
+		s.log.Warnf("[⚠️] %d connection(s) still open after the shutdown delay, forcing them closed", s.conns.Load())
+		if cerr := s.server.Close(); cerr != nil {
+			err = errors.Join(err, cerr)
+		}
+	}
+
+	if s.conf.OnShutdownComplete != nil {
+		s.conf.OnShutdownComplete()
+	}
+
+	if err == nil {
+		err = s.waitGoroutines(ctx)
+	}
+
+	// The components get a delay of their own, so they are closed even when the server used up its deadline.
+	lifecycleCtx, lifecycleCancel := context.WithTimeout(context.WithoutCancel(ctx), s.conf.ShutdownDelay)
+	defer lifecycleCancel()
+
+	err = errors.Join(err, s.lifecycle.Shutdown(lifecycleCtx))
+
 	s.buildMessage(
 		err,
 		"Successfully shutdown api service...",
@@ -76,18 +154,104 @@ func (s *ls) gracefullShutdown(ctx context.Context, close chan os.Signal) {
 	)
 }
 
+// drainReportInterval is the interval at which the connections still draining are logged on shutdown.
+const drainReportInterval = time.Second
+
+// trackConn counts the open connections of the server, see http.Server.ConnState.
+func (s *ls) trackConn(_ net.Conn, state http.ConnState) {
+	switch state {
+	case http.StateNew:
+		s.conns.Add(1)
+	case http.StateHijacked, http.StateClosed:
+		s.conns.Add(-1)
+	}
+}
+
+// reportDraining logs the number of open connections every drainReportInterval until the returned function is called,
+// with the time left before the deadline of the context.
+func (s *ls) reportDraining(ctx context.Context) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(drainReportInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				n := s.conns.Load()
+				if n == 0 {
+					continue
+				}
+				if deadline, ok := ctx.Deadline(); ok {
+					s.log.Infof("[⏳] Draining %d connection(s), %s left", n, time.Until(deadline).Round(time.Millisecond))
+				} else {
+					s.log.Infof("[⏳] Draining %d connection(s)", n)
+				}
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// TrackGoroutine adds a goroutine to the wait group waited on shutdown.
+// The returned function is safe to call more than once.
+func (s *ls) TrackGoroutine() func() {
+	var once sync.Once
+	s.inFlight.Add(1)
+	return func() {
+		once.Do(s.inFlight.Done)
+	}
+}
+
+func (s *ls) Lifecycle() *Lifecycle {
+	return s.lifecycle
+}
+
+// waitGoroutines waits for the tracked goroutines to finish, or returns the context error once it is done.
+func (s *ls) waitGoroutines(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 type ls struct {
-	server *http.Server
-	conf   ltp.LankyServerConf
-	host   string
-	log    *logrus.Logger
+	server   *http.Server
+	conf     ltp.LankyServerConf
+	host     string
+	log      *logrus.Logger
+	inFlight sync.WaitGroup
+	conns    atomic.Int64 // The open connections, counted by trackConn.
+	listener net.Listener // The listener served by Serve, set by NewWithListener.
+
+	lifecycle *Lifecycle
 }
 
 // New creates a new instance of LankyServer with the given parameters.
 // It initializes the server with the provided handler, configuration, and logger.
 // If the logger is nil, it creates a new instance of llog with default settings.
-// The server is configured with the provided host, address, and read timeout.
-// If the configuration specifies a write timeout or idle timeout, they are also set on the server.
+// The server is configured with the provided host, address, read timeout and read header timeout,
+// the latter defaulting to 10 seconds to protect against slow header (Slowloris) attacks.
+// If the configuration specifies a write timeout, idle timeout or max header bytes, they are also set on the server.
+// When HandlerTimeout is set, the handler is wrapped with http.TimeoutHandler.
+// When EnableRecovery is set, the handler is wrapped with the Recovery middleware.
+// When EnableAccessLog is set, the handler is wrapped with the AccessLog middleware, logging with the given logger.
+// When Cors is set, the handler is wrapped with the CORS middleware.
+// When EnableProfiling is set, the pprof and expvar endpoints are mounted ahead of the handler.
+// Every request carries the logger in its context, see ContextLogger.
 // The created LankyServer instance is returned.
 func New(
 	handler http.Handler,
@@ -98,6 +262,7 @@ func New(
 		host = "localhost"
 		addr = "8080"
 		rto  = time.Second * 60
+		rht  = time.Second * 10
 	)
 
 	if log == nil {
@@ -119,10 +284,47 @@ func New(
 		rto = conf.ReadTimeout
 	}
 
+	if conf.ReadHeaderTimeout > 0 {
+		rht = conf.ReadHeaderTimeout
+	}
+
+	if conf.HandlerTimeout > 0 {
+		msg := conf.HandlerTimeoutMsg
+		if len(msg) == 0 {
+			msg = http.StatusText(http.StatusServiceUnavailable)
+		}
+		handler = http.TimeoutHandler(handler, conf.HandlerTimeout, msg)
+	}
+
+	if conf.EnableRecovery {
+		handler = Recovery(log, conf.IsProduction)(handler)
+	}
+
+	if conf.EnableAccessLog {
+		handler = AccessLog(log)(handler)
+	}
+
+	if conf.Cors != nil {
+		handler = Cors(*conf.Cors)(handler)
+	}
+
+	if conf.EnableProfiling {
+		prefix := profilingPathPrefix(conf.ProfilingPathPrefix)
+		handler = withProfiling(handler, prefix)
+		log.Warnf("[🔬] Profiling endpoints enabled on %s", prefix)
+	}
+
+	handler = ContextLogger(log)(handler)
+
 	server := &http.Server{
-		Addr:        fmt.Sprintf(":%s", addr),
-		ReadTimeout: rto,
-		Handler:     handler,
+		Addr:              fmt.Sprintf(":%s", addr),
+		ReadTimeout:       rto,
+		ReadHeaderTimeout: rht,
+		Handler:           handler,
+	}
+
+	if conf.MaxHeaderBytes > 0 {
+		server.MaxHeaderBytes = conf.MaxHeaderBytes
 	}
 
 	if conf.WriteTimeout > 0 {
@@ -133,18 +335,42 @@ func New(
 		server.IdleTimeout = conf.IdleTimeout
 	}
 
-	return &ls{
-		host:   host,
-		log:    log,
-		conf:   conf,
-		server: server,
+	s := &ls{
+		host:      host,
+		log:       log,
+		conf:      conf,
+		server:    server,
+		lifecycle: NewLifecycle(log),
 	}
+	server.ConnState = s.trackConn
+
+	return s
+}
+
+// NewWithListener creates a new instance of LankyServer like New, served on the given already bound listener
+// by Serve instead of the configured host and address, e.g. an ephemeral port in tests or a socket passed by systemd.
+//
+// Example usage:
+//
+//	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+//	server := lanky_server.NewWithListener(ln, handler, conf, log)
+//	go server.Serve(ctx, close)
+func NewWithListener(
+	ln net.Listener,
+	handler http.Handler,
+	conf ltp.LankyServerConf,
+	log *logrus.Logger,
+) LankyServer {
+	s := New(handler, conf, log).(*ls)
+	s.listener = ln
+	s.server.Addr = ln.Addr().String()
+	return s
 }
 
 func (s *ls) buildMessage(err error, success, failed string) {
 	if err == nil {
 		s.log.Info(success)
 	} else {
-		s.log.Fatal(failed)
+		s.log.Error(failed)
 	}
 }
@@ -0,0 +1,68 @@
+package lanky_server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	ltp "github.com/the-lanky/go/types"
+)
+
+func TestShutdownHooksOrder(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu    sync.Mutex
+		steps []string
+	)
+	step := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		steps = append(steps, name)
+	}
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	conf := ltp.LankyServerConf{
+		ShutdownDelay:      time.Second,
+		OnShutdownStart:    func(context.Context) { step("start") },
+		OnShutdownComplete: func() { step("complete") },
+	}
+
+	s := NewWithListener(ln, http.NotFoundHandler(), conf, log)
+	s.Lifecycle().RegisterFunc("component", func(context.Context) error {
+		step("component")
+		return nil
+	})
+
+	close := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	go func() {
+		defer func() { done <- struct{}{} }()
+		s.Serve(context.Background(), close)
+	}()
+
+	close <- os.Interrupt
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the server did not shut down")
+	}
+
+	want := []string{"start", "complete", "component"}
+	if !reflect.DeepEqual(steps, want) {
+		t.Errorf("shutdown steps = %v, want %v", steps, want)
+	}
+}
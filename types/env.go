@@ -0,0 +1,124 @@
+package lanky_types
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envTag is the struct tag read by LoadFromEnv.
+// Its value is the name of the environment variable, optionally followed by ",required".
+const envTag = "env"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// LoadFromEnv populates the fields of the given configuration struct pointer from the environment variables
+// named by their `env` struct tag, e.g. `env:"PG_HOST"` or `env:"RMQ_DSN,required"`.
+// Fields without the tag are left untouched, as are tagged fields whose variable is not set,
+// so defaults assigned before the call are kept.
+// Supported field types are string, bool, integers, floats and time.Duration (parsed with time.ParseDuration),
+// and pointers to them, e.g. *bool to tell an unset variable from a false one.
+// Parsing failures and required fields that are still empty are returned together as one aggregated error.
+//
+// Example usage:
+//
+//	var conf lanky_types.LankyPostgreConf
+//	if err := lanky_types.LoadFromEnv(&conf); err != nil {
+//	    log.Fatal(err)
+//	}
+func LoadFromEnv(conf any) error {
+	rv := reflect.ValueOf(conf)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("LoadFromEnv expects a non-nil pointer to a struct")
+	}
+
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	var errs []error
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+
+		tag, ok := field.Tag.Lookup(envTag)
+		if !ok || !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		required := opts == "required"
+
+		value, found := os.LookupEnv(name)
+		if found && len(value) > 0 {
+			if err := setField(rv.Field(i), value); err != nil {
+				errs = append(errs, fmt.Errorf("%s (%s): %w", name, field.Name, err))
+				continue
+			}
+		}
+
+		if required && rv.Field(i).IsZero() {
+			errs = append(errs, fmt.Errorf("%s (%s) is required", name, field.Name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// setField parses the raw environment value according to the kind of the field and assigns it.
+// A pointer field is assigned a new value.
+func setField(field reflect.Value, value string) error {
+	if field.Kind() == reflect.Pointer {
+		elem := reflect.New(field.Type().Elem())
+		if err := setField(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package lanky_types
+
+import "testing"
+
+func TestLoadFromEnvPointerField(t *testing.T) {
+	type conf struct {
+		Durable *bool `env:"LANKY_TEST_DURABLE"`
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		want  *bool
+	}{
+		{name: "unset", value: "", want: nil},
+		{name: "false", value: "false", want: func() *bool { b := false; return &b }()},
+		{name: "true", value: "true", want: func() *bool { b := true; return &b }()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LANKY_TEST_DURABLE", tt.value)
+
+			var c conf
+			if err := LoadFromEnv(&c); err != nil {
+				t.Fatalf("LoadFromEnv() error = %v", err)
+			}
+
+			switch {
+			case tt.want == nil && c.Durable != nil:
+				t.Fatalf("Durable = %v, want nil", *c.Durable)
+			case tt.want != nil && (c.Durable == nil || *c.Durable != *tt.want):
+				t.Fatalf("Durable = %v, want %v", c.Durable, *tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFromEnvInvalidPointerValue(t *testing.T) {
+	t.Setenv("RMQ_QUEUE_DURABLE", "maybe")
+
+	var c LankyRabbitConf
+	if err := LoadFromEnv(&c); err == nil {
+		t.Fatal("expected an error for an invalid boolean")
+	}
+	if c.QueueDurable != nil {
+		t.Fatalf("QueueDurable = %v, want nil", *c.QueueDurable)
+	}
+}
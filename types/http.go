@@ -1,13 +1,43 @@
 package lanky_types
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // LankyServerConf represents the configuration for a Lanky server.
 type LankyServerConf struct {
-	Host          string        // Host specifies the hostname or IP address on which the server should listen.
-	Addr          string        // Addr specifies the network address on which the server should listen.
-	ReadTimeout   time.Duration // ReadTimeout specifies the maximum duration for reading the entire request.
-	WriteTimeout  time.Duration // WriteTimeout specifies the maximum duration before timing out writes of the response.
-	IdleTimeout   time.Duration // IdleTimeout specifies the maximum amount of time to wait for the next request when keep-alives are enabled.
-	ShutdownDelay time.Duration // ShutdownDelay specifies the delay before forcefully shutting down the server.
+	Host              string        `env:"SERVER_HOST"`                // Host specifies the hostname or IP address on which the server should listen.
+	Addr              string        `env:"SERVER_ADDR"`                // Addr specifies the network address on which the server should listen.
+	ReadTimeout       time.Duration `env:"SERVER_READ_TIMEOUT"`        // ReadTimeout specifies the maximum duration for reading the entire request.
+	ReadHeaderTimeout time.Duration `env:"SERVER_READ_HEADER_TIMEOUT"` // ReadHeaderTimeout specifies the maximum duration for reading the request headers. Defaults to 10 seconds.
+	MaxHeaderBytes    int           `env:"SERVER_MAX_HEADER_BYTES"`    // MaxHeaderBytes specifies the maximum size of the request headers. Defaults to http.DefaultMaxHeaderBytes (1 MB).
+	HandlerTimeout    time.Duration `env:"SERVER_HANDLER_TIMEOUT"`     // HandlerTimeout specifies the maximum duration of a handler, after which a 503 is returned and the request context is cancelled.
+	HandlerTimeoutMsg string        `env:"SERVER_HANDLER_TIMEOUT_MSG"` // HandlerTimeoutMsg specifies the body of the timeout response. Defaults to "Service Unavailable".
+	WriteTimeout      time.Duration `env:"SERVER_WRITE_TIMEOUT"`       // WriteTimeout specifies the maximum duration before timing out writes of the response.
+	IdleTimeout       time.Duration `env:"SERVER_IDLE_TIMEOUT"`        // IdleTimeout specifies the maximum amount of time to wait for the next request when keep-alives are enabled.
+	ShutdownDelay     time.Duration `env:"SERVER_SHUTDOWN_DELAY"`      // ShutdownDelay specifies the delay before forcefully shutting down the server.
+
+	EnableProfiling     bool   `env:"SERVER_ENABLE_PROFILING"`      // EnableProfiling mounts the pprof and expvar endpoints ahead of the handler. Keep it off unless diagnosing.
+	ProfilingPathPrefix string `env:"SERVER_PROFILING_PATH_PREFIX"` // ProfilingPathPrefix is the path the profiling endpoints are mounted on. Defaults to /debug/pprof.
+
+	Cors *LankyCorsConf // Cors enables the CORS middleware ahead of the handler when set.
+
+	EnableRecovery bool `env:"SERVER_ENABLE_RECOVERY"` // EnableRecovery wraps the handler with the Recovery middleware, answering the panics with a 500 error body.
+	IsProduction   bool `env:"SERVER_IS_PRODUCTION"`   // IsProduction hides the panic stack from the error bodies of the Recovery middleware.
+
+	EnableAccessLog bool `env:"SERVER_ENABLE_ACCESS_LOG"` // EnableAccessLog wraps the handler with the AccessLog middleware, logging a line per request.
+
+	OnShutdownStart    func(ctx context.Context) // OnShutdownStart is invoked right before the server starts shutting down, e.g. to deregister from service discovery.
+	OnShutdownComplete func()                    // OnShutdownComplete is invoked right after the server shutdown returns, before the components are closed, e.g. to flush metrics.
+}
+
+// LankyCorsConf represents the configuration of the CORS middleware.
+type LankyCorsConf struct {
+	AllowedOrigins   []string      // AllowedOrigins lists the origins allowed to call the API, "*" allowing any. Defaults to any.
+	AllowedMethods   []string      // AllowedMethods lists the methods allowed in cross-origin requests. Defaults to GET, POST, PUT, PATCH, DELETE and HEAD.
+	AllowedHeaders   []string      // AllowedHeaders lists the request headers allowed in cross-origin requests. Defaults to Accept, Authorization, Content-Type and X-Request-Id.
+	ExposedHeaders   []string      // ExposedHeaders lists the response headers readable by the browser.
+	AllowCredentials bool          // AllowCredentials allows the browser to send cookies and credentials.
+	MaxAge           time.Duration // MaxAge specifies how long the browser may cache a preflight response. Zero leaves it to the browser.
 }
@@ -4,18 +4,23 @@ import "time"
 
 // LankyMongoConf represents the configuration options for connecting to a MongoDB database.
 type LankyMongoConf struct {
-	Protocol          string        // The protocol to use for the connection (e.g., "mongodb").
-	Host              string        // The hostname or IP address of the MongoDB server.
-	User              string        // The username for authentication.
-	Password          string        // The password for authentication.
-	Database          string        // The name of the database to connect to.
-	Port              string        // The port number for the MongoDB server.
-	OptionParameter   string        // Additional options for the connection.
-	ReadPreferrence   string        // The read preference for the connection.
-	ConnectionTimeout time.Duration // The timeout for establishing a connection.
-	MaxConnIdleTime   time.Duration // The maximum time a connection can remain idle.
-	HeartbeatInterval time.Duration // The interval for sending heartbeat messages.
-	MaxPoolSize       uint          // The maximum number of connections in the connection pool.
-	MinPoolSize       uint          // The minimum number of connections in the connection pool.
-	EnabledMonitor    bool          // Whether to enable monitoring of the connection.
+	Protocol          string        `env:"MONGO_PROTOCOL,required"`  // The protocol to use for the connection (e.g., "mongodb").
+	Host              string        `env:"MONGO_HOST"`               // The hostname or IP address of the MongoDB server.
+	User              string        `env:"MONGO_USER,required"`      // The username for authentication.
+	Password          string        `env:"MONGO_PASSWORD"`           // The password for authentication.
+	Database          string        `env:"MONGO_DATABASE"`           // The name of the database to connect to.
+	Port              string        `env:"MONGO_PORT"`               // The port number for the MongoDB server.
+	OptionParameter   string        `env:"MONGO_OPTION_PARAMETER"`   // Additional options for the connection.
+	ReadPreferrence   string        `env:"MONGO_READ_PREFERENCE"`    // The read preference for the connection.
+	ConnectionTimeout time.Duration `env:"MONGO_CONNECTION_TIMEOUT"` // The timeout for establishing a connection.
+	MaxConnIdleTime   time.Duration `env:"MONGO_MAX_CONN_IDLE_TIME"` // The maximum time a connection can remain idle.
+	HeartbeatInterval time.Duration `env:"MONGO_HEARTBEAT_INTERVAL"` // The interval for sending heartbeat messages.
+	MaxPoolSize       uint          `env:"MONGO_MAX_POOL_SIZE"`      // The maximum number of connections in the connection pool.
+	MinPoolSize       uint          `env:"MONGO_MIN_POOL_SIZE"`      // The minimum number of connections in the connection pool.
+	EnabledMonitor    bool          `env:"MONGO_ENABLED_MONITOR"`    // Whether to enable monitoring of the connection.
+	MonitorLevel      string        `env:"MONGO_MONITOR_LEVEL"`      // The verbosity of the monitor: off, commands (names and durations only) or full (redacted command bodies). Defaults to full.
+	MonitorDebug      bool          `env:"MONGO_MONITOR_DEBUG"`      // Whether the full monitor also logs the reply bodies. Never enable it in production, replies may hold PII.
+	TLSCAFile         string        `env:"MONGO_TLS_CA_FILE"`        // The path to the PEM encoded CA certificate used to verify the server.
+	TLSCertKeyFile    string        `env:"MONGO_TLS_CERT_KEY_FILE"`  // The path to the PEM file containing both the client certificate and its private key.
+	TLSInsecure       bool          `env:"MONGO_TLS_INSECURE"`       // Whether to skip verification of the server certificate. Never enable it in production.
 }
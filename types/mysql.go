@@ -0,0 +1,25 @@
+package lanky_types
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LankyMySqlConf represents the configuration options for connecting to a MySQL database.
+type LankyMySqlConf struct {
+	Host                   string         `env:"MYSQL_HOST"`                     // The hostname or IP address of the MySQL server.
+	Port                   string         `env:"MYSQL_PORT"`                     // The port number of the MySQL server.
+	User                   string         `env:"MYSQL_USER"`                     // The username for authenticating with the MySQL server.
+	Password               string         `env:"MYSQL_PASSWORD"`                 // The password for authenticating with the MySQL server.
+	DbName                 string         `env:"MYSQL_DBNAME"`                   // The name of the MySQL database.
+	Charset                string         `env:"MYSQL_CHARSET"`                  // The charset of the connection. Defaults to utf8mb4.
+	TimeZone               string         `env:"MYSQL_TIMEZONE"`                 // The location used to parse DATETIME values, e.g. "UTC" or "Asia/Jakarta". Defaults to Local.
+	EnableDebug            bool           `env:"MYSQL_ENABLE_DEBUG"`             // Whether to enable debug mode for the MySQL connection.
+	MaximumIdleConnection  int            `env:"MYSQL_MAX_IDLE_CONNECTION"`      // The maximum number of idle connections in the connection pool.
+	MaximumOpenConnection  int            `env:"MYSQL_MAX_OPEN_CONNECTION"`      // The maximum number of open connections in the connection pool.
+	ConnectionMaxLifeTime  time.Duration  `env:"MYSQL_CONNECTION_MAX_LIFETIME"`  // The maximum lifetime of a connection in the connection pool.
+	SkipDefaultTransaction bool           `env:"MYSQL_SKIP_DEFAULT_TRANSACTION"` // Whether to skip the default transaction for each connection.
+	SlowSqlThreshold       time.Duration  `env:"MYSQL_SLOW_SQL_THRESHOLD"`       // The threshold duration for logging slow SQL queries.
+	Logger                 *logrus.Logger // The logger instance for logging MySQL-related messages.
+}
@@ -4,22 +4,47 @@ import (
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
 // LankyPostgreConf represents the configuration options for connecting to a PostgreSQL database.
 type LankyPostgreConf struct {
-	Host                   string         // The hostname or IP address of the PostgreSQL server.
-	Port                   string         // The port number of the PostgreSQL server.
-	User                   string         // The username for authenticating with the PostgreSQL server.
-	Password               string         // The password for authenticating with the PostgreSQL server.
-	DbName                 string         // The name of the PostgreSQL database.
-	SslMode                string         // The SSL mode for the PostgreSQL connection.
-	TimeZone               string         // The timezone to use for the PostgreSQL connection.
-	EnableDebug            bool           // Whether to enable debug mode for the PostgreSQL connection.
-	MaximumIdleConnection  int            // The maximum number of idle connections in the connection pool.
-	MaximumOpenConnection  int            // The maximum number of open connections in the connection pool.
-	ConnectionMaxLifeTime  time.Duration  // The maximum lifetime of a connection in the connection pool.
-	SkipDefaultTransaction bool           // Whether to skip the default transaction for each connection.
-	SlowSqlThreshold       time.Duration  // The threshold duration for logging slow SQL queries.
+	Host                   string         `env:"PG_HOST"`                     // The hostname or IP address of the PostgreSQL server.
+	Port                   string         `env:"PG_PORT"`                     // The port number of the PostgreSQL server.
+	User                   string         `env:"PG_USER"`                     // The username for authenticating with the PostgreSQL server.
+	Password               string         `env:"PG_PASSWORD"`                 // The password for authenticating with the PostgreSQL server.
+	DbName                 string         `env:"PG_DBNAME"`                   // The name of the PostgreSQL database.
+	AppName                string         `env:"PG_APP_NAME"`                 // The application_name of the connections, shown in pg_stat_activity. Defaults to the service name of the logger.
+	SslMode                string         `env:"PG_SSLMODE"`                  // The SSL mode for the PostgreSQL connection.
+	TimeZone               string         `env:"PG_TIMEZONE"`                 // The timezone to use for the PostgreSQL connection.
+	EnableDebug            bool           `env:"PG_ENABLE_DEBUG"`             // Whether to enable debug mode for the PostgreSQL connection.
+	MaximumIdleConnection  int            `env:"PG_MAX_IDLE_CONNECTION"`      // The maximum number of idle connections in the connection pool.
+	MaximumOpenConnection  int            `env:"PG_MAX_OPEN_CONNECTION"`      // The maximum number of open connections in the connection pool.
+	ConnectionMaxLifeTime  time.Duration  `env:"PG_CONNECTION_MAX_LIFETIME"`  // The maximum lifetime of a connection in the connection pool.
+	SkipDefaultTransaction bool           `env:"PG_SKIP_DEFAULT_TRANSACTION"` // Whether to skip the default transaction for each connection.
+	SlowSqlThreshold       time.Duration  `env:"PG_SLOW_SQL_THRESHOLD"`       // The threshold duration for logging slow SQL queries.
+	WarmUpConnections      int            `env:"PG_WARM_UP_CONNECTIONS"`      // The number of connections opened and pinged at construction to prime the pool, capped to the idle connections.
 	Logger                 *logrus.Logger // The logger instance for logging PostgreSQL-related messages.
+
+	// OnSlowQuery is invoked with the SQL, the duration and the affected rows of every query slower than
+	// SlowSqlThreshold, e.g. to push slow-query events to an APM. It is ignored when SlowSqlThreshold is not set.
+	OnSlowQuery func(sql string, duration time.Duration, rows int64)
+
+	// PrepareStmt caches the prepared statements of the queries, saving their planning on the next executions.
+	// The statements are prepared per connection of the pool, so each connection holds a copy of the cache,
+	// growing with MaximumOpenConnection. Leave it off behind a pooler in transaction mode, e.g. PgBouncer,
+	// since a statement prepared on one server connection is unknown to the others.
+	PrepareStmt bool `env:"PG_PREPARE_STMT"`
+
+	AutoMigrate     bool  `env:"PG_AUTO_MIGRATE"` // Whether to auto-migrate MigrationModels when the connection is created.
+	MigrationModels []any // The models migrated at construction when AutoMigrate is enabled.
+
+	// ReadReplicas lists the read replicas of the database. When set, read queries are routed to them
+	// and writes keep going to the primary. Only the connection fields of each replica are used,
+	// their connection pools are sized like the pool of the primary.
+	ReadReplicas []LankyPostgreConf
+
+	// Plugins are registered on the connection with gorm's Use, in order, e.g. tracing, caching or sharding plugins.
+	// The construction fails if a plugin cannot be registered.
+	Plugins []gorm.Plugin
 }
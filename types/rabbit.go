@@ -1,14 +1,63 @@
 package lanky_types
 
-import "time"
+import (
+	"crypto/tls"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rabbitmq/amqp091-go"
+)
 
 // LankyRabbitConf represents the configuration for RabbitMQ.
 type LankyRabbitConf struct {
-	Dsn                string        // The RabbitMQ DSN.
-	ExchangeName       string        // The name of the exchange.
-	ExchangeType       string        // The type of the exchange.
-	ExchangeQueue      string        // The name of the exchange queue.
-	Secret             string        // Secret represents the secret value used for authentication or encryption. Should be 24 character long
-	EnableDebugMessage bool          // EnableDebugMessage indicates whether debug messages should be enabled.
-	RejoinDelay        time.Duration // RejoinDelay represents the duration to wait before attempting to rejoin a connection.
+	Dsn                string        `env:"RMQ_DSN"`                    // The RabbitMQ DSN. When set it overrides the structured connection fields below.
+	Username           string        `env:"RMQ_USERNAME"`               // The username, used to build the DSN when Dsn is empty. Defaults to guest.
+	Password           string        `env:"RMQ_PASSWORD"`               // The password, used to build the DSN when Dsn is empty. Defaults to guest.
+	Host               string        `env:"RMQ_HOST"`                   // The host of the broker, used to build the DSN when Dsn is empty.
+	Port               string        `env:"RMQ_PORT"`                   // The port of the broker, used to build the DSN when Dsn is empty. Defaults to 5672, or 5671 with TLS.
+	VHost              string        `env:"RMQ_VHOST"`                  // The virtual host, used to build the DSN when Dsn is empty. Defaults to "/".
+	AuthMechanism      string        `env:"RMQ_AUTH_MECHANISM"`         // The SASL mechanism: PLAIN (default), AMQPLAIN or EXTERNAL (client certificate).
+	ExchangeName       string        `env:"RMQ_EXCHANGE_NAME,required"` // The name of the exchange.
+	ExchangeType       string        `env:"RMQ_EXCHANGE_TYPE,required"` // The type of the exchange.
+	ExchangeQueue      string        `env:"RMQ_EXCHANGE_QUEUE"`         // The name of the exchange queue. Required unless QueueExclusive is set, an empty name then letting the broker generate one.
+	Namespace          string        `env:"RMQ_NAMESPACE"`              // Namespace prefixes the exchange, the queues and the routing keys, e.g. a tenant id isolating traffic on a shared broker. Topics stay unprefixed.
+	Secret             string        `env:"RMQ_SECRET"`                 // Secret represents the secret value used for authentication or encryption. Should be 16, 24 or 32 character long. Required unless DisableEncryption is set.
+	EnableDebugMessage bool          `env:"RMQ_ENABLE_DEBUG_MESSAGE"`   // EnableDebugMessage indicates whether debug messages should be enabled.
+	LogEveryMessage    bool          `env:"RMQ_LOG_EVERY_MESSAGE"`      // LogEveryMessage logs every consumed message at Info level instead of Debug. Keep it off for busy consumers.
+	RejoinDelay        time.Duration `env:"RMQ_REJOIN_DELAY"`           // RejoinDelay represents the duration to wait before attempting to rejoin a connection.
+	Heartbeat          time.Duration `env:"RMQ_HEARTBEAT"`              // Heartbeat is the interval of the heartbeats detecting a dead connection. Defaults to 10 seconds.
+	DialTimeout        time.Duration `env:"RMQ_DIAL_TIMEOUT"`           // DialTimeout bounds the TCP dial and the handshake of the connection. Defaults to 30 seconds.
+	QueueMaxPriority   uint8         `env:"RMQ_QUEUE_MAX_PRIORITY"`     // QueueMaxPriority sets the x-max-priority argument of the queue, enabling message priorities. Zero disables it.
+	QueueArgs          amqp091.Table // QueueArgs are the arguments of the queue declaration, e.g. x-queue-type, x-message-ttl or x-max-length.
+	ExchangeArgs       amqp091.Table // ExchangeArgs are the arguments of the exchange declaration, e.g. alternate-exchange.
+	PreviousSecrets    []string      // PreviousSecrets are the secrets still accepted to decrypt during a rotation, see lanky_crypto.WithKeyRotation. Requires EnableKeyRotation.
+	EnableIntegrity    bool          `env:"RMQ_ENABLE_INTEGRITY"`    // EnableIntegrity indicates whether messages carry an HMAC that is verified on consume. Publishers and consumers must agree.
+	EnableCompression  bool          `env:"RMQ_ENABLE_COMPRESSION"`  // EnableCompression gzips the messages before encrypting them. Publishers and consumers must agree.
+	EnableKeyRotation  bool          `env:"RMQ_ENABLE_KEY_ROTATION"` // EnableKeyRotation prefixes the messages with the version of their secret so PreviousSecrets can be rotated freely. With EnableIntegrity, consumers enabling it still read the messages of publishers that did not yet.
+	DisableEncryption  bool          `env:"RMQ_DISABLE_ENCRYPTION"`  // DisableEncryption publishes and consumes the messages in plain text, ignoring Secret. Local development only.
+	MaxMessageBytes    int           `env:"RMQ_MAX_MESSAGE_BYTES"`   // MaxMessageBytes is the limit of the encrypted body of a published message. Defaults to 16 MiB, negative disables it.
+
+	QueueDurable    *bool `env:"RMQ_QUEUE_DURABLE"`     // QueueDurable sets whether the queue of Listen survives a broker restart. Defaults to true.
+	QueueAutoDelete bool  `env:"RMQ_QUEUE_AUTO_DELETE"` // QueueAutoDelete deletes the queue of Listen once its last consumer is gone.
+	QueueExclusive  bool  `env:"RMQ_QUEUE_EXCLUSIVE"`   // QueueExclusive restricts the queue of Listen to the connection, deleting it once the connection is closed.
+
+	SlowConsumeThreshold time.Duration `env:"RMQ_SLOW_CONSUME_THRESHOLD"` // SlowConsumeThreshold logs a warning for every Consume call slower than it. Zero disables it.
+
+	TLSCAFile      string      `env:"RMQ_TLS_CA_FILE"`       // The path to the PEM encoded CA certificate used to verify the broker, e.g. a private CA.
+	TLSCertKeyFile string      `env:"RMQ_TLS_CERT_KEY_FILE"` // The path to the PEM file containing both the client certificate and its private key.
+	TLSInsecure    bool        `env:"RMQ_TLS_INSECURE"`      // Whether to skip verification of the broker certificate. Never enable it in production.
+	TLSConfig      *tls.Config // TLSConfig is used as is to dial the broker when set, ignoring the other TLS fields.
+
+	EnableMetrics     bool                  `env:"RMQ_ENABLE_METRICS"` // EnableMetrics indicates whether Prometheus metrics for publish and consume should be collected.
+	MetricsRegisterer prometheus.Registerer // MetricsRegisterer is where the metrics are registered. Defaults to prometheus.DefaultRegisterer.
+
+	// Deduplicator skips the deliveries whose MessageId was already seen, e.g. lanky_rabbitmq.NewMemoryDeduplicator.
+	// The ID is only marked once the message was consumed successfully, so a failed message is consumed again when redelivered or replayed. It is optional.
+	Deduplicator interface {
+		Seen(id string) bool
+		Mark(id string)
+	}
+
+	OnDisconnect func(err error) // OnDisconnect is invoked when the connection to the broker is lost, before reconnecting. It is optional.
+	OnReconnect  func()          // OnReconnect is invoked once the connection is restored and the consumers started again. It is optional.
 }